@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	_ "music/docs"
 	"music/internal/controller"
@@ -22,6 +23,13 @@ import (
 // @description API for managing music library
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token from /auth/login or an API key, sent as "Bearer <token>"
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	// Инициализация конфигурации
 	cfg, err := config.InitConfig()
@@ -29,38 +37,85 @@ func main() {
 		log.Fatalf("Failed to initialize config: %v", err)
 	}
 
-	fmt.Printf("All config: %+v\n", cfg)
+	fmt.Printf("All config: %+v\n", cfg.Redacted())
 
 	// Инициализация логгера
 	_log := logger.NewLogger()
 
 	// Подключение к базе данных
-	dbConn, err := db.NewDB(cfg)
+	dbConn, err := db.Open(cfg)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 	defer dbConn.Close()
 
-	// Инициализация репозитория
-	repo := repository.NewMainRepository(dbConn.PostgreSQL)
+	// Подкоманда migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(dbConn, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Применение миграций при запуске
+	if cfg.DB.MigrateOnStart {
+		if err := runMigrate(dbConn, []string{"up"}); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	}
 
-	// Инициализация сервиса
-	service := services.NewMainService(repo, http.DefaultClient, cfg, _log)
+	// Инициализация репозиториев
+	repo := repository.NewMainRepository(dbConn.Conn(), dbConn.Driver)
+	artistRepo := repository.NewArtistRepository(dbConn.Conn(), dbConn.Driver)
+	albumRepo := repository.NewAlbumRepository(dbConn.Conn(), dbConn.Driver)
+	playlistRepo := repository.NewPlaylistRepository(dbConn.Conn(), dbConn.Driver)
+	userRepo := repository.NewUserRepository(dbConn.Conn(), dbConn.Driver)
+	apiKeyRepo := repository.NewAPIKeyRepository(dbConn.Conn(), dbConn.Driver)
+	revisionRepo := repository.NewRevisionRepository(dbConn.Conn(), dbConn.Driver)
+	lyricsRepo := repository.NewLyricsRepository(dbConn.Conn(), dbConn.Driver)
+
+	// Инициализация сервисов
+	enrichment := services.NewEnrichmentClient(cfg, _log)
+	service := services.NewMainService(repo, revisionRepo, lyricsRepo, enrichment, cfg, _log)
+	artistService := services.NewArtistService(artistRepo, repo, _log)
+	albumService := services.NewAlbumService(albumRepo, _log)
+	playlistService := services.NewPlaylistService(playlistRepo, repo, _log)
+	authService, err := services.NewAuthService(userRepo, cfg, _log)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	userService := services.NewUserService(userRepo, _log)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, _log)
 
 	mux := mux.NewRouter()
 
-	// Инициализация контроллера
-	ctrl := controller.NewMainController(service, mux, _log)
+	// Каждый маршрут проверяет право доступа своей роли
+	auth := controller.NewAuthenticator(authService, apiKeyService, cfg, _log)
+
+	// Инициализация контроллеров
+	ctrl := controller.NewMainController(service, auth, cfg, mux, _log)
+	artistCtrl := controller.NewArtistController(artistService, auth, mux, _log)
+	albumCtrl := controller.NewAlbumController(albumService, auth, mux, _log)
+	playlistCtrl := controller.NewPlaylistController(playlistService, auth, mux, _log)
+	authCtrl := controller.NewAuthController(authService, mux, _log)
+	userCtrl := controller.NewUserController(userService, auth, mux, _log)
+	apiKeyCtrl := controller.NewAPIKeyController(apiKeyService, auth, mux, _log)
 
 	ctrl.RegisterHandlers()
+	artistCtrl.RegisterHandlers()
+	albumCtrl.RegisterHandlers()
+	playlistCtrl.RegisterHandlers()
+	authCtrl.RegisterHandlers()
+	userCtrl.RegisterHandlers()
+	apiKeyCtrl.RegisterHandlers()
 
 	mux.HandleFunc("/swagger.json", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		http.ServeFile(w, r, "./docs/swagger.json")
 	})
-	
+
 	mux.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
-		httpSwagger.URL("/swagger.json"), 
+		httpSwagger.URL("/swagger.json"),
 		httpSwagger.DocExpansion("none"),
 		httpSwagger.DomID("swagger-ui"),
 	))
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"music/internal/db"
+)
+
+var errMigrateUsage = errors.New("usage: music_api migrate up|down|status")
+
+// runMigrate executes the migrate subcommand against the configured database.
+func runMigrate(dbConn *db.DB, args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		results, err := dbConn.MigrateUp(ctx)
+		for _, r := range results {
+			fmt.Printf("OK   %s (%s)\n", r.Source.Path, r.Duration.Round(time.Millisecond))
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		result, err := dbConn.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("OK   %s (%s)\n", result.Source.Path, result.Duration.Round(time.Millisecond))
+	case "status":
+		statuses, err := dbConn.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("    Applied At                  Migration")
+		fmt.Println("    =======================================")
+		for _, st := range statuses {
+			appliedAt := "Pending"
+			if !st.AppliedAt.IsZero() {
+				appliedAt = st.AppliedAt.Format(time.ANSIC)
+			}
+			fmt.Printf("    %-24s -- %s\n", appliedAt, st.Source.Path)
+		}
+	default:
+		return errMigrateUsage
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/internal/services"
+	"music/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type AlbumController struct {
+	service *services.AlbumService
+	auth    *Authenticator
+	log     *logger.Logger
+	router  *mux.Router
+}
+
+func NewAlbumController(service *services.AlbumService, auth *Authenticator, m *mux.Router, log *logger.Logger) *AlbumController {
+	return &AlbumController{
+		service: service,
+		auth:    auth,
+		log:     log,
+		router:  m,
+	}
+}
+
+func (c *AlbumController) RegisterHandlers() {
+	c.router.Handle("/albums", c.auth.Require(model.PermSongsRead, c.handleAlbums)).Methods("GET")
+	c.router.Handle("/albums", c.auth.Require(model.PermSongsWrite, c.handleAlbums)).Methods("POST")
+	c.router.Handle("/albums/{id}", c.auth.Require(model.PermSongsRead, c.handleAlbumByID)).Methods("GET")
+	c.router.Handle("/albums/{id}", c.auth.Require(model.PermSongsWrite, c.handleAlbumByID)).Methods("PUT")
+	c.router.Handle("/albums/{id}", c.auth.Require(model.PermSongsDelete, c.handleAlbumByID)).Methods("DELETE")
+	c.router.Handle("/albums/{id}/tracks", c.auth.Require(model.PermSongsRead, c.GetAlbumTracks)).Methods("GET")
+}
+
+func (c *AlbumController) handleAlbums(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		c.GetAllAlbums(w, r)
+	case http.MethodPost:
+		c.AddAlbum(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *AlbumController) handleAlbumByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid album ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c.GetAlbum(w, r, id)
+	case http.MethodPut:
+		c.UpdateAlbum(w, r, id)
+	case http.MethodDelete:
+		c.DeleteAlbum(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// albumErrorStatus maps repository errors to HTTP status codes.
+func albumErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrAlbumNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrTrackTaken):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrAlbumTitle),
+		errors.Is(err, repository.ErrAlbumArtist),
+		errors.Is(err, repository.ErrArtistName),
+		errors.Is(err, repository.ErrArtistNotFound),
+		errors.Is(err, repository.ErrTrackNumber),
+		errors.Is(err, repository.ErrSongNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetAllAlbums godoc
+// @Summary Get all albums
+// @Description Get albums with filters and pagination
+// @Tags albums
+// @Produce json
+// @Param artist query int false "Filter by artist ID"
+// @Param group query string false "Filter by artist name"
+// @Param title query string false "Filter by album title"
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.Album
+// @Router /albums [get]
+func (c *AlbumController) GetAllAlbums(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET all albums request", logrus.Fields{})
+
+	filters := map[string]string{
+		"artist": r.URL.Query().Get("artist"),
+		"group":  r.URL.Query().Get("group"),
+		"title":  r.URL.Query().Get("title"),
+	}
+
+	limit, offset := pagination(r)
+
+	albums, err := c.service.GetAllAlbums(filters, limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get albums", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(albums); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetAlbum godoc
+// @Summary Get album by ID
+// @Description Get album details with its track list
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} model.Album
+// @Failure 404 {object} map[string]string
+// @Router /albums/{id} [get]
+func (c *AlbumController) GetAlbum(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling GET album request", logrus.Fields{"album_id": id})
+
+	album, err := c.service.GetAlbumByID(id)
+	if err != nil {
+		c.log.Error("Failed to get album", logrus.Fields{"error": err, "album_id": id})
+		http.Error(w, err.Error(), albumErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(album); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetAlbumTracks godoc
+// @Summary Get album tracks
+// @Description Get songs of an album ordered by disc and track number
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {array} model.Song
+// @Failure 404 {object} map[string]string
+// @Router /albums/{id}/tracks [get]
+func (c *AlbumController) GetAlbumTracks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid album ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling GET album tracks request", logrus.Fields{"album_id": id})
+
+	songs, err := c.service.GetAlbumTracks(id)
+	if err != nil {
+		c.log.Error("Failed to get album tracks", logrus.Fields{"error": err, "album_id": id})
+		http.Error(w, err.Error(), albumErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(songs); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// AddAlbum godoc
+// @Summary Add new album
+// @Description Add new album; the artist is resolved by group_name or given by artist_id
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param album body model.Album true "Album data with optional track list"
+// @Success 201 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /albums [post]
+func (c *AlbumController) AddAlbum(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST album request", logrus.Fields{})
+
+	var album model.Album
+	if err := json.NewDecoder(r.Body).Decode(&album); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.service.AddAlbum(album, principal(r))
+	if err != nil {
+		c.log.Error("Failed to add album", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), albumErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]int{"id": id}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// UpdateAlbum godoc
+// @Summary Update album
+// @Description Update album data; when tracks is present the track list is replaced
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param album body model.Album true "Updated album data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /albums/{id} [put]
+func (c *AlbumController) UpdateAlbum(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling PUT album request", logrus.Fields{"album_id": id})
+
+	var album model.Album
+	if err := json.NewDecoder(r.Body).Decode(&album); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdateAlbum(id, album, principal(r)); err != nil {
+		c.log.Error("Failed to update album", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), albumErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// DeleteAlbum godoc
+// @Summary Delete album
+// @Description Delete album; its songs are kept without album
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /albums/{id} [delete]
+func (c *AlbumController) DeleteAlbum(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling DELETE album request", logrus.Fields{"album_id": id})
+
+	if err := c.service.DeleteAlbum(id, principal(r)); err != nil {
+		c.log.Error("Failed to delete album", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), albumErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/internal/services"
+	"music/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyController struct {
+	service *services.APIKeyService
+	auth    *Authenticator
+	log     *logger.Logger
+	router  *mux.Router
+}
+
+func NewAPIKeyController(service *services.APIKeyService, auth *Authenticator, m *mux.Router, log *logger.Logger) *APIKeyController {
+	return &APIKeyController{
+		service: service,
+		auth:    auth,
+		log:     log,
+		router:  m,
+	}
+}
+
+func (c *APIKeyController) RegisterHandlers() {
+	c.router.Handle("/api-keys", c.auth.Require(model.PermUsersManage, c.GetAllKeys)).Methods("GET")
+	c.router.Handle("/api-keys", c.auth.Require(model.PermUsersManage, c.CreateKey)).Methods("POST")
+	c.router.Handle("/api-keys/{id}", c.auth.Require(model.PermUsersManage, c.RevokeKey)).Methods("DELETE")
+}
+
+// apiKeyErrorStatus maps API key errors to HTTP status codes.
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAPIKeyName),
+		errors.Is(err, services.ErrAPIKeyScopes):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetAllKeys godoc
+// @Summary Get API keys
+// @Description List API keys with their scopes and last use, including revoked ones (admin only)
+// @Tags api-keys
+// @Produce json
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.APIKey
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api-keys [get]
+func (c *APIKeyController) GetAllKeys(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET all api keys request", logrus.Fields{})
+
+	limit, offset := pagination(r)
+
+	keys, err := c.service.GetAllKeys(limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get api keys", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// CreateKey godoc
+// @Summary Create API key
+// @Description Create an API key for a machine client (admin only). The key is only returned in this response; send it as X-API-Key or "Authorization: Bearer <key>".
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body model.APIKeyRequest true "Key name and scopes"
+// @Success 201 {object} model.NewAPIKey
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api-keys [post]
+func (c *APIKeyController) CreateKey(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST api key request", logrus.Fields{})
+
+	var req model.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := PrincipalFromContext(r.Context())
+
+	key, err := c.service.CreateKey(req, principal.UserID)
+	if err != nil {
+		c.log.Error("Failed to create api key", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), apiKeyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// RevokeKey godoc
+// @Summary Revoke API key
+// @Description Revoke an API key; it stays listed with revoked_at set (admin only)
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api-keys/{id} [delete]
+func (c *APIKeyController) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid api key ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid api key ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling DELETE api key request", logrus.Fields{"api_key_id": id})
+
+	if err := c.service.RevokeKey(id); err != nil {
+		c.log.Error("Failed to revoke api key", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), apiKeyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
@@ -0,0 +1,284 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/internal/services"
+	"music/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type ArtistController struct {
+	service *services.ArtistService
+	auth    *Authenticator
+	log     *logger.Logger
+	router  *mux.Router
+}
+
+func NewArtistController(service *services.ArtistService, auth *Authenticator, m *mux.Router, log *logger.Logger) *ArtistController {
+	return &ArtistController{
+		service: service,
+		auth:    auth,
+		log:     log,
+		router:  m,
+	}
+}
+
+func (c *ArtistController) RegisterHandlers() {
+	c.router.Handle("/artists", c.auth.Require(model.PermSongsRead, c.handleArtists)).Methods("GET")
+	c.router.Handle("/artists", c.auth.Require(model.PermSongsWrite, c.handleArtists)).Methods("POST")
+	c.router.Handle("/artists/{id}", c.auth.Require(model.PermSongsRead, c.handleArtistByID)).Methods("GET")
+	c.router.Handle("/artists/{id}", c.auth.Require(model.PermSongsWrite, c.handleArtistByID)).Methods("PUT")
+	c.router.Handle("/artists/{id}", c.auth.Require(model.PermSongsDelete, c.handleArtistByID)).Methods("DELETE")
+	c.router.Handle("/artists/{id}/songs", c.auth.Require(model.PermSongsRead, c.GetArtistSongs)).Methods("GET")
+}
+
+func (c *ArtistController) handleArtists(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		c.GetAllArtists(w, r)
+	case http.MethodPost:
+		c.AddArtist(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *ArtistController) handleArtistByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid artist ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid artist ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c.GetArtist(w, r, id)
+	case http.MethodPut:
+		c.UpdateArtist(w, r, id)
+	case http.MethodDelete:
+		c.DeleteArtist(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// artistErrorStatus maps repository errors to HTTP status codes.
+func artistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrArtistNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrArtistExists), errors.Is(err, repository.ErrArtistHasSongs):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrArtistName):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetAllArtists godoc
+// @Summary Get all artists
+// @Description Get artists with optional name filter and pagination
+// @Tags artists
+// @Produce json
+// @Param name query string false "Filter by name (case and whitespace insensitive)"
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.Artist
+// @Router /artists [get]
+func (c *ArtistController) GetAllArtists(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET all artists request", logrus.Fields{})
+
+	limit, offset := pagination(r)
+
+	artists, err := c.service.GetAllArtists(r.URL.Query().Get("name"), limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get artists", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(artists); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetArtist godoc
+// @Summary Get artist by ID
+// @Description Get artist details by its ID
+// @Tags artists
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} model.Artist
+// @Failure 404 {object} map[string]string
+// @Router /artists/{id} [get]
+func (c *ArtistController) GetArtist(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling GET artist request", logrus.Fields{"artist_id": id})
+
+	artist, err := c.service.GetArtistByID(id)
+	if err != nil {
+		c.log.Error("Failed to get artist", logrus.Fields{"error": err, "artist_id": id})
+		http.Error(w, err.Error(), artistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(artist); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// AddArtist godoc
+// @Summary Add new artist
+// @Description Add new artist; names are unique ignoring case and extra whitespace
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param artist body model.Artist true "Artist data"
+// @Success 201 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /artists [post]
+func (c *ArtistController) AddArtist(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST artist request", logrus.Fields{})
+
+	var artist model.Artist
+	if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.service.AddArtist(artist)
+	if err != nil {
+		c.log.Error("Failed to add artist", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), artistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]int{"id": id}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// UpdateArtist godoc
+// @Summary Update artist
+// @Description Update artist name and metadata
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Param artist body model.Artist true "Updated artist data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /artists/{id} [put]
+func (c *ArtistController) UpdateArtist(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling PUT artist request", logrus.Fields{"artist_id": id})
+
+	var artist model.Artist
+	if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdateArtist(id, artist); err != nil {
+		c.log.Error("Failed to update artist", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), artistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// DeleteArtist godoc
+// @Summary Delete artist
+// @Description Delete an artist that has no songs
+// @Tags artists
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /artists/{id} [delete]
+func (c *ArtistController) DeleteArtist(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling DELETE artist request", logrus.Fields{"artist_id": id})
+
+	if err := c.service.DeleteArtist(id); err != nil {
+		c.log.Error("Failed to delete artist", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), artistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// GetArtistSongs godoc
+// @Summary Get artist songs
+// @Description Get songs of an artist with pagination
+// @Tags artists
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.Song
+// @Failure 404 {object} map[string]string
+// @Router /artists/{id}/songs [get]
+func (c *ArtistController) GetArtistSongs(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid artist ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid artist ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling GET artist songs request", logrus.Fields{"artist_id": id})
+
+	limit, offset := pagination(r)
+
+	songs, err := c.service.GetArtistSongs(id, limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get artist songs", logrus.Fields{"error": err, "artist_id": id})
+		http.Error(w, err.Error(), artistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(songs); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
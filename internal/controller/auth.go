@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/internal/services"
+	"music/pkg/config"
+	"music/pkg/logger"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type AuthController struct {
+	service *services.AuthService
+	log     *logger.Logger
+	router  *mux.Router
+}
+
+func NewAuthController(service *services.AuthService, m *mux.Router, log *logger.Logger) *AuthController {
+	return &AuthController{
+		service: service,
+		log:     log,
+		router:  m,
+	}
+}
+
+func (c *AuthController) RegisterHandlers() {
+	c.router.HandleFunc("/auth/register", c.Register).Methods("POST")
+	c.router.HandleFunc("/auth/login", c.Login).Methods("POST")
+}
+
+// authErrorStatus maps auth errors to HTTP status codes.
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrUserExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidUsername),
+		errors.Is(err, services.ErrInvalidPassword):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Register godoc
+// @Summary Register user
+// @Description Create a user account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body model.Credentials true "Username and password"
+// @Success 201 {object} model.User
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/register [post]
+func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST register request", logrus.Fields{})
+
+	var creds model.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.service.Register(creds)
+	if err != nil {
+		c.log.Error("Failed to register user", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), authErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange username and password for a bearer access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body model.Credentials true "Username and password"
+// @Success 200 {object} model.Token
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/login [post]
+func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST login request", logrus.Fields{})
+
+	var creds model.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, err := c.service.Login(creds)
+	if err != nil {
+		c.log.Error("Failed to log in", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), authErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(token); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the caller stored by Authenticator.Require.
+func PrincipalFromContext(ctx context.Context) (model.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(model.Principal)
+	return principal, ok
+}
+
+// principal returns the caller of r, or the zero principal for anonymous
+// requests.
+func principal(r *http.Request) model.Principal {
+	p, _ := PrincipalFromContext(r.Context())
+	return p
+}
+
+// Authenticator guards routes with bearer access tokens or API keys and
+// checks the permission each route declares.
+type Authenticator struct {
+	service *services.AuthService
+	keys    *services.APIKeyService
+	log     *logger.Logger
+	// anonymous holds what callers without credentials may do.
+	anonymous model.Role
+}
+
+func NewAuthenticator(service *services.AuthService, keys *services.APIKeyService, cfg *config.Config, log *logger.Logger) *Authenticator {
+	a := &Authenticator{
+		service: service,
+		keys:    keys,
+		log:     log,
+	}
+	if cfg.Auth.AnonymousRead {
+		a.anonymous = model.RoleViewer
+	}
+	return a
+}
+
+// Require returns a handler that runs h only for callers holding perm. The
+// caller is stored in the request context.
+func (a *Authenticator) Require(perm model.Permission, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential, ok := credentials(r)
+		if !ok {
+			if a.anonymous.Can(perm) {
+				h(w, r)
+				return
+			}
+			accessDenied(w, http.StatusUnauthorized, model.AccessError{
+				Error:              "unauthorized",
+				Message:            "missing bearer token or api key",
+				RequiredPermission: perm,
+			})
+			return
+		}
+
+		var (
+			principal model.Principal
+			err       error
+		)
+		if services.IsAPIKey(credential) {
+			principal, err = a.keys.Authenticate(credential)
+		} else {
+			principal, err = a.service.Authenticate(credential)
+		}
+		if err != nil {
+			a.log.Error("Rejected credentials", logrus.Fields{"error": err, "path": r.URL.Path})
+			if !errors.Is(err, services.ErrInvalidToken) && !errors.Is(err, services.ErrInvalidAPIKey) {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			accessDenied(w, http.StatusUnauthorized, model.AccessError{
+				Error:              "unauthorized",
+				Message:            err.Error(),
+				RequiredPermission: perm,
+			})
+			return
+		}
+
+		if !principal.Can(perm) {
+			a.log.Error("Permission denied", logrus.Fields{
+				"user":       principal.Username,
+				"api_key_id": principal.APIKeyID,
+				"permission": perm,
+				"path":       r.URL.Path,
+			})
+			message := fmt.Sprintf("role %s does not grant %s", principal.Role, perm)
+			if principal.APIKeyID != 0 {
+				message = fmt.Sprintf("api key is not scoped for %s", perm)
+			}
+			accessDenied(w, http.StatusForbidden, model.AccessError{
+				Error:              "forbidden",
+				Message:            message,
+				RequiredPermission: perm,
+				Role:               principal.Role,
+			})
+			return
+		}
+
+		h(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// credentials reads an API key from X-API-Key, or an access token or API key
+// from "Authorization: Bearer" (or "ApiKey").
+func credentials(r *http.Request) (string, bool) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key, true
+	}
+
+	scheme, credential, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "ApiKey")) {
+		return "", false
+	}
+	credential = strings.TrimSpace(credential)
+	return credential, credential != ""
+}
+
+func accessDenied(w http.ResponseWriter, status int, body model.AccessError) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="music-api"`)
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -2,11 +2,20 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"music/internal/model"
+	"music/internal/repository"
 	"music/internal/services"
+	"music/pkg/config"
+	"music/pkg/jsonpatch"
 	"music/pkg/logger"
+	"music/pkg/lrc"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -14,22 +23,44 @@ import (
 
 type MainController struct {
 	service *services.MainService
+	auth    *Authenticator
 	log     *logger.Logger
 	router  *mux.Router
+	// requireIfMatch makes If-Match mandatory on song writes.
+	requireIfMatch bool
 }
 
-func NewMainController(service *services.MainService, m *mux.Router, log *logger.Logger) *MainController {
+func NewMainController(service *services.MainService, auth *Authenticator, cfg *config.Config, m *mux.Router, log *logger.Logger) *MainController {
 	return &MainController{
-		service: service,
-		log:     log,
-		router:  m,
+		service:        service,
+		auth:           auth,
+		log:            log,
+		router:         m,
+		requireIfMatch: cfg.Server.RequireIfMatch,
 	}
 }
 
 func (c *MainController) RegisterHandlers() {
-	c.router.HandleFunc("/songs", c.handleSongs).Methods("GET", "POST")
-	c.router.HandleFunc("/songs/{id}", c.handleSongByID).Methods("GET", "PUT", "DELETE")
-	c.router.HandleFunc("/songs/{id}/text", c.GetSongText).Methods("GET")
+	c.router.Handle("/songs", c.auth.Require(model.PermSongsRead, c.handleSongs)).Methods("GET")
+	c.router.Handle("/songs", c.auth.Require(model.PermSongsWrite, c.handleSongs)).Methods("POST")
+	c.router.Handle("/songs/search", c.auth.Require(model.PermSongsRead, c.SearchSongs)).Methods("GET")
+	c.router.Handle("/songs/import", c.auth.Require(model.PermSongsWrite, c.ImportSongs)).Methods("POST")
+	c.router.Handle("/songs/export", c.auth.Require(model.PermSongsRead, c.ExportSongs)).Methods("GET")
+	c.router.Handle("/songs/{id}", c.auth.Require(model.PermSongsRead, c.handleSongByID)).Methods("GET")
+	c.router.Handle("/songs/{id}", c.auth.Require(model.PermSongsWrite, c.handleSongByID)).Methods("PUT", "PATCH")
+	c.router.Handle("/songs/{id}", c.auth.Require(model.PermSongsDelete, c.handleSongByID)).Methods("DELETE")
+	c.router.Handle("/songs/{id}/text", c.auth.Require(model.PermSongsRead, c.GetSongText)).Methods("GET")
+	c.router.Handle("/songs/{id}/restore", c.auth.Require(model.PermSongsDelete, c.RestoreSong)).Methods("POST")
+	c.router.Handle("/songs/{id}/revisions", c.auth.Require(model.PermSongsRead, c.GetRevisions)).Methods("GET")
+	c.router.Handle("/songs/{id}/revisions/{rev}", c.auth.Require(model.PermSongsRead, c.GetRevision)).Methods("GET")
+	c.router.Handle("/songs/{id}/revisions/{rev}/revert", c.auth.Require(model.PermSongsWrite, c.RevertSong)).Methods("POST")
+	c.router.Handle("/songs/{id}/lyrics/diff", c.auth.Require(model.PermSongsRead, c.DiffLyrics)).Methods("GET")
+	c.router.Handle("/songs/{id}/lyrics/synced", c.auth.Require(model.PermSongsRead, c.GetSyncedLyrics)).Methods("GET")
+	c.router.Handle("/songs/{id}/lyrics/synced", c.auth.Require(model.PermSongsWrite, c.SetSyncedLyrics)).Methods("PUT")
+	c.router.Handle("/songs/{id}/lyrics/synced", c.auth.Require(model.PermSongsWrite, c.DeleteSyncedLyrics)).Methods("DELETE")
+	c.router.Handle("/trash", c.auth.Require(model.PermSongsDelete, c.GetTrash)).Methods("GET")
+	c.router.Handle("/trash", c.auth.Require(model.PermSongsPurge, c.PurgeTrash)).Methods("DELETE")
+	c.router.Handle("/enrichment/status", c.auth.Require(model.PermUsersManage, c.GetEnrichmentStatus)).Methods("GET")
 }
 
 func (c *MainController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +92,8 @@ func (c *MainController) handleSongByID(w http.ResponseWriter, r *http.Request)
 		c.GetSong(w, r, id)
 	case http.MethodPut:
 		c.UpdateSong(w, r, id)
+	case http.MethodPatch:
+		c.PatchSong(w, r, id)
 	case http.MethodDelete:
 		c.DeleteSong(w, r, id)
 	default:
@@ -68,49 +101,246 @@ func (c *MainController) handleSongByID(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// ifMatch checks the If-Match header of a write to song id and returns the
+// version the write must still find, or 0 without the header. ok is false
+// when the request has been answered.
+func (c *MainController) ifMatch(w http.ResponseWriter, r *http.Request, id int) (version int, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if c.requireIfMatch {
+			c.log.Error("Missing If-Match header", logrus.Fields{"song_id": id})
+			http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+			return 0, false
+		}
+		return 0, true
+	}
+
+	song, err := c.service.GetSongByID(id)
+	if err != nil {
+		c.log.Error("Failed to get song", logrus.Fields{"error": err, "song_id": id})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return 0, false
+	}
+	if !etagMatches(header, song.Version, false) {
+		c.log.Error("If-Match does not match", logrus.Fields{"song_id": id, "if_match": header})
+		w.Header().Set("ETag", songETag(song.Version))
+		http.Error(w, repository.ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return song.Version, true
+}
+
+// songErrorStatus maps repository errors to HTTP status codes.
+func songErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrSongNotFound),
+		errors.Is(err, repository.ErrSongNotInTrash),
+		errors.Is(err, repository.ErrRevisionNotFound),
+		errors.Is(err, repository.ErrNoSyncedLyrics):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrTrackTaken),
+		errors.Is(err, jsonpatch.ErrTestFailed):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, jsonpatch.ErrPathNotFound),
+		errors.Is(err, services.ErrReadOnlyField),
+		errors.Is(err, services.ErrInvalidSong),
+		errors.Is(err, services.ErrDiffTooLarge),
+		errors.Is(err, lrc.ErrOrder):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, repository.ErrEmptySearch),
+		errors.Is(err, repository.ErrInvalidFilter),
+		errors.Is(err, repository.ErrInvalidCursor),
+		errors.Is(err, repository.ErrInvalidSort),
+		errors.Is(err, repository.ErrAlbumNotFound),
+		errors.Is(err, repository.ErrArtistName),
+		errors.Is(err, repository.ErrTrackNumber),
+		errors.Is(err, model.ErrInvalidDate),
+		errors.Is(err, services.ErrSongRequired),
+		errors.Is(err, services.ErrInvalidCSV),
+		errors.Is(err, services.ErrExportFormat),
+		errors.Is(err, services.ErrEmptyLRC),
+		errors.Is(err, services.ErrSectionType),
+		errors.Is(err, lrc.ErrSyntax),
+		errors.Is(err, lrc.ErrTimestamp),
+		errors.Is(err, jsonpatch.ErrInvalidPatch):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrEnrichmentUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, services.ErrEnrichmentTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, services.ErrEnrichment):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pagination reads the limit (default 10) and offset query parameters.
+func pagination(r *http.Request) (int, int) {
+	query := r.URL.Query()
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// songQueryParams maps the query parameters of the song list to repository
+// filter keys. Each text filter also takes <param>_match.
+var songQueryParams = map[string]string{
+	"group":        "group",
+	"song":         "song",
+	"release_date": "release",
+	"release_from": "release_from",
+	"release_to":   "release_to",
+	"year":         "year",
+	"lyrics":       "lyrics",
+	"link":         "link",
+	"album":        "album",
+}
+
+// songQuery reads the filters, match modes, order and pagination of the
+// song list.
+func songQuery(r *http.Request) (model.SongQuery, error) {
+	values := r.URL.Query()
+	sort, err := parseSort(values.Get("sort"))
+	if err != nil {
+		return model.SongQuery{}, err
+	}
+	q := model.SongQuery{
+		Sort:    sort,
+		Filters: make(map[string]string),
+		Match:   make(map[string]model.MatchMode),
+		Cursor:  values.Get("cursor"),
+	}
+	for param, key := range songQueryParams {
+		q.Filters[key] = values.Get(param)
+		if mode := values.Get(param + "_match"); mode != "" {
+			q.Match[key] = model.MatchMode(mode)
+		}
+	}
+	q.Limit, q.Offset = pagination(r)
+	return q, nil
+}
+
+// parseSort reads a sort parameter such as "group,release_date:desc".
+func parseSort(value string) ([]model.SortField, error) {
+	var fields []model.SortField
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, dir, _ := strings.Cut(part, ":")
+		field := model.SortField{Key: key}
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			return nil, fmt.Errorf("%w: direction of %s must be asc or desc", repository.ErrInvalidSort, key)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
 // GetAllSongs godoc
 // @Summary Get all songs
-// @Description Get songs with filters and pagination
+// @Description Get songs with filters and pagination. Pages can be walked with limit/offset or, without skipped or repeated rows, with the cursor returned in X-Next-Cursor. Text filters match exactly unless <param>_match selects prefix, contains (both case-insensitive) or fuzzy (trigram similarity, group and song only). With fuzzy matching each song carries a similarity score and the best matches come first.
 // @Tags songs
 // @Param group query string false "Filter by group"
+// @Param group_match query string false "Match mode for group" Enums(exact, prefix, contains, fuzzy)
 // @Param song query string false "Filter by song title"
-// @Param release_date query string false "Filter by release date"
+// @Param song_match query string false "Match mode for song" Enums(exact, prefix, contains, fuzzy)
+// @Param release_date query string false "Filter by release date (dd.mm.yyyy, mm.yyyy or yyyy); a partial date matches its whole month or year"
+// @Param release_from query string false "Released on or after this date (dd.mm.yyyy, mm.yyyy or yyyy)" example(01.01.2000)
+// @Param release_to query string false "Released on or before this date; a partial date includes its whole month or year" example(2010)
+// @Param year query int false "Released in this year" example(2006)
+// @Param lyrics query string false "Filter by lyrics"
+// @Param lyrics_match query string false "Match mode for lyrics" Enums(exact, prefix, contains)
+// @Param link query string false "Filter by link"
+// @Param link_match query string false "Match mode for link" Enums(exact, prefix, contains)
+// @Param album query int false "Filter by album ID"
+// @Param sort query string false "Comma-separated sort keys, each optionally followed by :asc (default) or :desc. Keys: group, song, release_date, created_at, id. release_date sorts chronologically with undated songs first. Ties are broken by id ascending. Default: id, or best similarity first with fuzzy filters." example(group,release_date:desc)
 // @Param limit query int false "Limit (default 10)"
-// @Param offset query int false "Offset (default 0)"
+// @Param offset query int false "Offset (default 0); ignored with cursor"
+// @Param cursor query string false "Opaque cursor from X-Next-Cursor of the previous page"
 // @Success 200 {array} model.Song
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, absent on the last page"
+// @Header 200 {string} Link "URL of the next page (rel=next)"
+// @Failure 400 {object} map[string]string
 // @Router /songs [get]
 func (c *MainController) GetAllSongs(w http.ResponseWriter, r *http.Request) {
 	c.log.Info("Handling GET all songs request", logrus.Fields{})
 
-	filters := map[string]string{
-		"group":   r.URL.Query().Get("group"),
-		"song":    r.URL.Query().Get("song"),
-		"release": r.URL.Query().Get("release_date"),
-		"lyrics":  r.URL.Query().Get("lyrics"),
-		"link":    r.URL.Query().Get("link"),
+	q, err := songQuery(r)
+	if err != nil {
+		c.log.Error("Invalid song query", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	query := r.URL.Query()
+	page, err := c.service.GetAllSongs(q)
+	if err != nil {
+		c.log.Error("Failed to get songs", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
 
-	limit, _ := strconv.Atoi(query.Get("limit"))
-	if limit <= 0 {
-		limit = 10
+	if page.NextCursor != "" {
+		next := *r.URL
+		values := next.Query()
+		values.Del("offset")
+		values.Set("cursor", page.NextCursor)
+		next.RawQuery = values.Encode()
+
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
 	}
 
-	offset, _ := strconv.Atoi(query.Get("offset"))
-	if offset < 0 {
-		offset = 0
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page.Songs); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
+}
 
-	songs, err := c.service.GetAllSongs(filters, limit, offset)
+// SearchSongs godoc
+// @Summary Search songs
+// @Description Full-text search over song title, group and lyrics, best matches first. Matched words are wrapped in <b></b> in title_highlight and snippet.
+// @Tags songs
+// @Produce json
+// @Param q query string true "Search text; on PostgreSQL \"phrases\", or and -word are supported"
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.SongSearchResult
+// @Failure 400 {object} map[string]string
+// @Router /songs/search [get]
+func (c *MainController) SearchSongs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	c.log.Info("Handling GET search songs request", logrus.Fields{"query": query})
+
+	limit, offset := pagination(r)
+
+	results, err := c.service.SearchSongs(query, limit, offset)
 	if err != nil {
-		c.log.Error("Failed to get songs", logrus.Fields{"error": err})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		c.log.Error("Failed to search songs", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), songErrorStatus(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(songs); err != nil {
+	if err := json.NewEncoder(w).Encode(results); err != nil {
 		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
@@ -122,7 +352,10 @@ func (c *MainController) GetAllSongs(w http.ResponseWriter, r *http.Request) {
 // @Tags songs
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 when it is still current"
 // @Success 200 {object} model.Song
+// @Header 200 {string} ETag "Version of the song"
+// @Success 304 "Not modified"
 // @Failure 404 {object} map[string]string
 // @Router /songs/{id} [get]
 func (c *MainController) GetSong(w http.ResponseWriter, r *http.Request, id int) {
@@ -135,6 +368,12 @@ func (c *MainController) GetSong(w http.ResponseWriter, r *http.Request, id int)
 		return
 	}
 
+	w.Header().Set("ETag", songETag(song.Version))
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, song.Version, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(song); err != nil {
 		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
@@ -143,15 +382,20 @@ func (c *MainController) GetSong(w http.ResponseWriter, r *http.Request, id int)
 }
 
 // GetSongText godoc
-// @Summary Get paginated song lyrics
-// @Description Get song text with pagination by verses
+// @Summary Get song lyrics by section
+// @Description Get the lyrics of a song split into labeled sections (intro, verse, chorus, bridge, outro), paginated by section. Sections come from markers such as [Chorus] or "Verse 2:" in the lyrics; unmarked blocks that occur more than once are taken as choruses and the rest as verses. With collapse, a repeated section is listed once with the number of times it is sung. format=text returns the selected sections as plain text with a [Label] line before each.
 // @Tags songs
+// @Produce json
 // @Produce plain
 // @Param id path int true "Song ID"
+// @Param type query string false "Comma-separated section types to keep, e.g. verse,chorus"
+// @Param collapse query bool false "List repeated sections once"
+// @Param format query string false "json (default) or text" Enums(json, text)
 // @Param page query int false "Page number (default 1)"
-// @Param per_page query int false "Verses per page (default 3)"
-// @Success 200 {string} string
+// @Param per_page query int false "Sections per page (default 3)"
+// @Success 200 {object} model.SongText
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /songs/{id}/text [get]
 func (c *MainController) GetSongText(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -164,26 +408,54 @@ func (c *MainController) GetSongText(w http.ResponseWriter, r *http.Request) {
 
 	c.log.Info("Handling GET song text request", logrus.Fields{"song_id": id})
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "text" {
+		http.Error(w, "format must be json or text", http.StatusBadRequest)
+		return
 	}
 
-	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
-	if perPage < 1 {
-		perPage = 3
+	q := model.SectionQuery{Page: 1, PerPage: 3}
+	if page, _ := strconv.Atoi(query.Get("page")); page > 0 {
+		q.Page = page
+	}
+	if perPage, _ := strconv.Atoi(query.Get("per_page")); perPage > 0 {
+		q.PerPage = perPage
+	}
+	for _, value := range query["type"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				q.Types = append(q.Types, name)
+			}
+		}
+	}
+	if value := query.Get("collapse"); value != "" {
+		q.Collapse, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "collapse must be true or false", http.StatusBadRequest)
+			return
+		}
 	}
 
-	text, err := c.service.GetSongText(id, page, perPage)
+	text, err := c.service.GetSongText(id, q)
 	if err != nil {
 		c.log.Error("Failed to get song text", logrus.Fields{"error": err})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := io.WriteString(w, services.FormatSections(text.Sections)); err != nil {
+			c.log.Error("Failed to write response", logrus.Fields{"error": err})
+		}
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/plain")
-	if _, err := w.Write([]byte(text)); err != nil {
-		c.log.Error("Failed to write response", logrus.Fields{"error": err})
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(text); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
@@ -197,6 +469,13 @@ func (c *MainController) GetSongText(w http.ResponseWriter, r *http.Request) {
 // @Success 201 {object} map[string]int
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 502 {object} map[string]string "The external API answered with an error"
+// @Failure 503 {object} map[string]string "Calls to the external API are paused after repeated failures"
+// @Failure 504 {object} map[string]string "The external API did not answer in time"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
 // @Router /songs [post]
 func (c *MainController) AddSong(w http.ResponseWriter, r *http.Request) {
 	c.log.Info("Handling POST song request", logrus.Fields{})
@@ -214,10 +493,10 @@ func (c *MainController) AddSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := c.service.AddSong(song)
+	id, err := c.service.AddSong(song, principal(r))
 	if err != nil {
 		c.log.Error("Failed to add song", logrus.Fields{"error": err})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), songErrorStatus(err))
 		return
 	}
 
@@ -230,15 +509,23 @@ func (c *MainController) AddSong(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSong godoc
 // @Summary Update song
-// @Description Update existing song data
+// @Description Replace all fields of an existing song; group_name and song_title are required
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag from GET /songs/{id}; the write applies only to that version"
 // @Param song body model.Song true "Updated song data"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 412 {object} map[string]string "The song changed since the given ETag"
+// @Failure 428 {object} map[string]string "If-Match is missing and REQUIRE_IF_MATCH is set"
 // @Router /songs/{id} [put]
 func (c *MainController) UpdateSong(w http.ResponseWriter, r *http.Request, id int) {
 	c.log.Info("Handling PUT song request", logrus.Fields{"song_id": id})
@@ -250,9 +537,14 @@ func (c *MainController) UpdateSong(w http.ResponseWriter, r *http.Request, id i
 		return
 	}
 
-	if err := c.service.UpdateSong(id, song); err != nil {
+	version, ok := c.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := c.service.UpdateSong(id, song, version, principal(r)); err != nil {
 		c.log.Error("Failed to update song", logrus.Fields{"error": err})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), songErrorStatus(err))
 		return
 	}
 
@@ -262,22 +554,105 @@ func (c *MainController) UpdateSong(w http.ResponseWriter, r *http.Request, id i
 	}
 }
 
+// maxPatchSize limits PATCH bodies.
+const maxPatchSize = 1 << 20
+
+// PatchSong godoc
+// @Summary Patch song
+// @Description Change some fields of a song. With Content-Type application/merge-patch+json (or application/json) the body is a JSON Merge Patch (RFC 7396): the given fields are replaced and null clears one. With application/json-patch+json it is a JSON Patch (RFC 6902): a list of add, remove, replace, move, copy and test operations applied together. id, artist_id, created_at, version and updated_at are read-only; the patched song must still have group_name and song_title.
+// @Tags songs
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag from GET /songs/{id}; the write applies only to that version"
+// @Param patch body object true "Merge patch or JSON Patch document"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "A test operation failed"
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 412 {object} map[string]string "The song changed since the given ETag"
+// @Failure 428 {object} map[string]string "If-Match is missing and REQUIRE_IF_MATCH is set"
+// @Router /songs/{id} [patch]
+func (c *MainController) PatchSong(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling PATCH song request", logrus.Fields{"song_id": id})
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/merge-patch+json", "application/json":
+		apply = jsonpatch.MergePatch
+	case "application/json-patch+json":
+		apply = jsonpatch.Apply
+	default:
+		c.log.Error("Unsupported patch format", logrus.Fields{"content_type": mediaType})
+		w.Header().Set("Accept-Patch", "application/merge-patch+json, application/json-patch+json")
+		http.Error(w, "Content-Type must be application/merge-patch+json or application/json-patch+json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		c.log.Error("Failed to read request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := c.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	err = c.service.PatchSong(id, version, principal(r), func(doc []byte) ([]byte, error) {
+		return apply(doc, patch)
+	})
+	if err != nil {
+		c.log.Error("Failed to patch song", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
 
 // DeleteSong godoc
 // @Summary Delete song
-// @Description Delete song from library
+// @Description Move a song to the trash; it can be restored until the trash is purged
 // @Tags songs
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag from GET /songs/{id}; the write applies only to that version"
 // @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 412 {object} map[string]string "The song changed since the given ETag"
+// @Failure 428 {object} map[string]string "If-Match is missing and REQUIRE_IF_MATCH is set"
 // @Router /songs/{id} [delete]
 func (c *MainController) DeleteSong(w http.ResponseWriter, r *http.Request, id int) {
 	c.log.Info("Handling DELETE song request", logrus.Fields{"song_id": id})
 
-	if err := c.service.DeleteSong(id); err != nil {
+	version, ok := c.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteSong(id, version, principal(r)); err != nil {
 		c.log.Error("Failed to delete song", logrus.Fields{"error": err})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), songErrorStatus(err))
 		return
 	}
 
@@ -286,3 +661,98 @@ func (c *MainController) DeleteSong(w http.ResponseWriter, r *http.Request, id i
 		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
 	}
 }
+
+// GetTrash godoc
+// @Summary List deleted songs
+// @Description List songs in the trash, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.Song
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /trash [get]
+func (c *MainController) GetTrash(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET trash request", logrus.Fields{})
+
+	limit, offset := pagination(r)
+
+	songs, err := c.service.GetTrash(limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get trash", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(songs); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// RestoreSong godoc
+// @Summary Restore deleted song
+// @Description Take a song out of the trash
+// @Tags trash
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string "The song is not in the trash"
+// @Failure 409 {object} map[string]string "Its album track position has been taken"
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /songs/{id}/restore [post]
+func (c *MainController) RestoreSong(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid song ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling POST song restore request", logrus.Fields{"song_id": id})
+
+	if err := c.service.RestoreSong(id, principal(r)); err != nil {
+		c.log.Error("Failed to restore song", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// PurgeTrash godoc
+// @Summary Purge trash
+// @Description Permanently remove songs deleted longer ago than TRASH_RETENTION (default 720h); requires songs:purge (admin only)
+// @Tags trash
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /trash [delete]
+func (c *MainController) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling DELETE trash request", logrus.Fields{})
+
+	purged, err := c.service.PurgeTrash()
+	if err != nil {
+		c.log.Error("Failed to purge trash", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"purged": purged}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
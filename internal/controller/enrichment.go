@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GetEnrichmentStatus godoc
+// @Summary Get external API client status
+// @Description Report the circuit breaker state and request counters of the client that fetches lyrics, release dates and links from the external API. While the state is "open", adding songs fails with 503 until retry_at.
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} model.EnrichmentStatus
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /enrichment/status [get]
+func (c *MainController) GetEnrichmentStatus(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET enrichment status request", logrus.Fields{})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.service.EnrichmentStatus()); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
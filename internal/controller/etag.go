@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+)
+
+// songETag is the entity tag of a song at version.
+func songETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists
+// the tag of version. If-Match compares strongly, so weak tags never match
+// there; If-None-Match compares weakly.
+func etagMatches(header string, version int, weak bool) bool {
+	tag := songETag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[len("W/"):]
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
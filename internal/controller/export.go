@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"music/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// exportWriter sends the response headers with the first byte of an
+// export, so an error before any row is written can still be answered with
+// an error status.
+type exportWriter struct {
+	w       http.ResponseWriter
+	headers func(http.Header)
+	started bool
+}
+
+func (e *exportWriter) start() {
+	if !e.started {
+		e.started = true
+		e.headers(e.w.Header())
+		e.w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	e.start()
+	return e.w.Write(p)
+}
+
+// ExportSongs godoc
+// @Summary Export songs
+// @Description Download every song matching the filters of GET /songs as a file. Rows are streamed from the database as they are read. limit, offset and cursor are ignored. A CSV export can be imported again with POST /songs/import.
+// @Tags songs
+// @Produce json
+// @Produce plain
+// @Param format query string false "csv, json (default), ndjson, or the playlist formats m3u, m3u8 and xspf with YouTube links as locations" Enums(csv, json, ndjson, m3u, m3u8, xspf)
+// @Param group query string false "Filter by group"
+// @Param group_match query string false "Match mode for group" Enums(exact, prefix, contains, fuzzy)
+// @Param song query string false "Filter by song title"
+// @Param song_match query string false "Match mode for song" Enums(exact, prefix, contains, fuzzy)
+// @Param release_date query string false "Filter by release date (dd.mm.yyyy, mm.yyyy or yyyy)"
+// @Param release_from query string false "Released on or after this date"
+// @Param release_to query string false "Released on or before this date"
+// @Param year query int false "Released in this year"
+// @Param lyrics query string false "Filter by lyrics"
+// @Param lyrics_match query string false "Match mode for lyrics" Enums(exact, prefix, contains)
+// @Param link query string false "Filter by link"
+// @Param link_match query string false "Match mode for link" Enums(exact, prefix, contains)
+// @Param album query int false "Filter by album ID"
+// @Param sort query string false "Sort keys as for GET /songs"
+// @Success 200 {array} model.Song
+// @Header 200 {string} Content-Disposition "attachment; filename=songs-YYYYMMDD.<format>"
+// @Failure 400 {object} map[string]string
+// @Router /songs/export [get]
+func (c *MainController) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET song export request", logrus.Fields{})
+
+	format := services.ExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = services.ExportJSON
+	}
+	switch format {
+	case services.ExportCSV, services.ExportJSON, services.ExportNDJSON,
+		services.ExportM3U, services.ExportM3U8, services.ExportXSPF:
+	default:
+		http.Error(w, services.ErrExportFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q, err := songQuery(r)
+	if err != nil {
+		c.log.Error("Invalid song query", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("songs-%s.%s", time.Now().Format("20060102"), format)
+	out := &exportWriter{w: w, headers: func(h http.Header) {
+		h.Set("Content-Type", format.ContentType())
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	}}
+
+	count, err := c.service.ExportSongs(out, q, format)
+	if err != nil {
+		c.log.Error("Failed to export songs", logrus.Fields{"error": err, "exported": count})
+		if !out.started {
+			http.Error(w, err.Error(), songErrorStatus(err))
+		}
+		return
+	}
+	// An empty NDJSON export writes nothing but is still a download.
+	out.start()
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxImportSize limits import bodies.
+const maxImportSize = 32 << 20
+
+// ImportSongs godoc
+// @Summary Import songs from CSV
+// @Description Add songs from a CSV document. The header names song fields: group_name and song_title are required; release_date, lyrics, youtube_link, album_id, disc_number and track_number are optional. Songs are inserted in batched transactions. Each row is reported as created, duplicate (already in the library or earlier in the file) or failed. With enrich=true, rows without lyrics are completed from the external API.
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param enrich query bool false "Fetch lyrics, release date and link for rows without lyrics"
+// @Param file body string true "CSV document"
+// @Success 200 {object} model.ImportReport
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /songs/import [post]
+func (c *MainController) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST song import request", logrus.Fields{})
+
+	if value := r.Header.Get("Content-Type"); value != "" {
+		mediaType, _, _ := mime.ParseMediaType(value)
+		switch mediaType {
+		case "text/csv", "application/csv", "text/plain":
+		default:
+			c.log.Error("Unsupported import format", logrus.Fields{"content_type": mediaType})
+			http.Error(w, "Content-Type must be text/csv", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	enrich := false
+	if value := r.URL.Query().Get("enrich"); value != "" {
+		var err error
+		if enrich, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "enrich must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := c.service.ImportSongs(http.MaxBytesReader(w, r.Body, maxImportSize), enrich, principal(r))
+	if err != nil {
+		c.log.Error("Failed to import songs", logrus.Fields{"error": err})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Import is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
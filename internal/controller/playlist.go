@@ -0,0 +1,385 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/internal/services"
+	"music/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type PlaylistController struct {
+	service *services.PlaylistService
+	auth    *Authenticator
+	log     *logger.Logger
+	router  *mux.Router
+}
+
+func NewPlaylistController(service *services.PlaylistService, auth *Authenticator, m *mux.Router, log *logger.Logger) *PlaylistController {
+	return &PlaylistController{
+		service: service,
+		auth:    auth,
+		log:     log,
+		router:  m,
+	}
+}
+
+func (c *PlaylistController) RegisterHandlers() {
+	c.router.Handle("/playlists", c.auth.Require(model.PermSongsRead, c.handlePlaylists)).Methods("GET")
+	c.router.Handle("/playlists", c.auth.Require(model.PermSongsWrite, c.handlePlaylists)).Methods("POST")
+	c.router.Handle("/playlists/import", c.auth.Require(model.PermSongsWrite, c.ImportPlaylist)).Methods("POST")
+	c.router.Handle("/playlists/{id}", c.auth.Require(model.PermSongsRead, c.handlePlaylistByID)).Methods("GET")
+	c.router.Handle("/playlists/{id}", c.auth.Require(model.PermSongsWrite, c.handlePlaylistByID)).Methods("PUT")
+	c.router.Handle("/playlists/{id}", c.auth.Require(model.PermSongsDelete, c.handlePlaylistByID)).Methods("DELETE")
+	c.router.Handle("/playlists/{id}/export", c.auth.Require(model.PermSongsRead, c.ExportPlaylist)).Methods("GET")
+	c.router.Handle("/playlists/{id}/entries", c.auth.Require(model.PermSongsWrite, c.AddEntry)).Methods("POST")
+	c.router.Handle("/playlists/{id}/entries/{entry_id}", c.auth.Require(model.PermSongsWrite, c.handleEntry)).Methods("PUT", "DELETE")
+}
+
+func (c *PlaylistController) handlePlaylists(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		c.GetAllPlaylists(w, r)
+	case http.MethodPost:
+		c.AddPlaylist(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *PlaylistController) handlePlaylistByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid playlist ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid playlist ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c.GetPlaylist(w, r, id)
+	case http.MethodPut:
+		c.UpdatePlaylist(w, r, id)
+	case http.MethodDelete:
+		c.DeletePlaylist(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *PlaylistController) handleEntry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		c.log.Error("Invalid playlist ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid playlist ID", http.StatusBadRequest)
+		return
+	}
+	entryID, err := strconv.Atoi(vars["entry_id"])
+	if err != nil {
+		c.log.Error("Invalid entry ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		c.MoveEntry(w, r, id, entryID)
+	case http.MethodDelete:
+		c.RemoveEntry(w, r, id, entryID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// playlistErrorStatus maps repository errors to HTTP status codes.
+func playlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrPlaylistNotFound), errors.Is(err, repository.ErrEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrPlaylistName), errors.Is(err, repository.ErrSongNotFound),
+		errors.Is(err, services.ErrPlaylistFile):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetAllPlaylists godoc
+// @Summary Get all playlists
+// @Description Get playlists without their entries
+// @Tags playlists
+// @Produce json
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.Playlist
+// @Router /playlists [get]
+func (c *PlaylistController) GetAllPlaylists(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET all playlists request", logrus.Fields{})
+
+	limit, offset := pagination(r)
+
+	playlists, err := c.service.GetAllPlaylists(limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get playlists", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(playlists); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetPlaylist godoc
+// @Summary Get playlist by ID
+// @Description Get playlist with its entries in order and songs expanded
+// @Tags playlists
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} model.Playlist
+// @Failure 404 {object} map[string]string
+// @Router /playlists/{id} [get]
+func (c *PlaylistController) GetPlaylist(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling GET playlist request", logrus.Fields{"playlist_id": id})
+
+	playlist, err := c.service.GetPlaylistByID(id)
+	if err != nil {
+		c.log.Error("Failed to get playlist", logrus.Fields{"error": err, "playlist_id": id})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(playlist); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// AddPlaylist godoc
+// @Summary Create playlist
+// @Description Create an empty playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param playlist body model.Playlist true "Playlist name and description"
+// @Success 201 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /playlists [post]
+func (c *PlaylistController) AddPlaylist(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST playlist request", logrus.Fields{})
+
+	var playlist model.Playlist
+	if err := json.NewDecoder(r.Body).Decode(&playlist); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.service.AddPlaylist(playlist)
+	if err != nil {
+		c.log.Error("Failed to add playlist", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]int{"id": id}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// UpdatePlaylist godoc
+// @Summary Rename playlist
+// @Description Update playlist name and description; entries are not touched
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param playlist body model.Playlist true "Playlist name and description"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /playlists/{id} [put]
+func (c *PlaylistController) UpdatePlaylist(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling PUT playlist request", logrus.Fields{"playlist_id": id})
+
+	var playlist model.Playlist
+	if err := json.NewDecoder(r.Body).Decode(&playlist); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdatePlaylist(id, playlist); err != nil {
+		c.log.Error("Failed to update playlist", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// DeletePlaylist godoc
+// @Summary Delete playlist
+// @Description Delete playlist and all of its entries
+// @Tags playlists
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /playlists/{id} [delete]
+func (c *PlaylistController) DeletePlaylist(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling DELETE playlist request", logrus.Fields{"playlist_id": id})
+
+	if err := c.service.DeletePlaylist(id); err != nil {
+		c.log.Error("Failed to delete playlist", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// AddEntry godoc
+// @Summary Add song to playlist
+// @Description Append a song to the playlist or insert it at a 1-based position
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param entry body model.PlaylistEntryRequest true "Song and optional position"
+// @Success 201 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /playlists/{id}/entries [post]
+func (c *PlaylistController) AddEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid playlist ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid playlist ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling POST playlist entry request", logrus.Fields{"playlist_id": id})
+
+	var req model.PlaylistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entryID, err := c.service.AddEntry(id, req.SongID, req.Position)
+	if err != nil {
+		c.log.Error("Failed to add playlist entry", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]int{"id": entryID}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// MoveEntry godoc
+// @Summary Move playlist entry
+// @Description Move an entry to a new 1-based position; other entries keep their place
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param entry_id path int true "Entry ID"
+// @Param entry body model.PlaylistEntryRequest true "New position"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /playlists/{id}/entries/{entry_id} [put]
+func (c *PlaylistController) MoveEntry(w http.ResponseWriter, r *http.Request, id, entryID int) {
+	c.log.Info("Handling PUT playlist entry request", logrus.Fields{"playlist_id": id, "entry_id": entryID})
+
+	var req model.PlaylistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.MoveEntry(id, entryID, req.Position); err != nil {
+		c.log.Error("Failed to move playlist entry", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// RemoveEntry godoc
+// @Summary Remove playlist entry
+// @Description Remove an entry from the playlist
+// @Tags playlists
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param entry_id path int true "Entry ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /playlists/{id}/entries/{entry_id} [delete]
+func (c *PlaylistController) RemoveEntry(w http.ResponseWriter, r *http.Request, id, entryID int) {
+	c.log.Info("Handling DELETE playlist entry request", logrus.Fields{"playlist_id": id, "entry_id": entryID})
+
+	if err := c.service.RemoveEntry(id, entryID); err != nil {
+		c.log.Error("Failed to remove playlist entry", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
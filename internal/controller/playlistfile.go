@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"music/pkg/playlistfile"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPlaylistFileSize limits uploaded playlist files.
+const maxPlaylistFileSize = 8 << 20
+
+// playlistMediaTypes maps the media types of uploads to playlist formats.
+var playlistMediaTypes = map[string]playlistfile.Format{
+	"audio/x-mpegurl":               playlistfile.M3U,
+	"audio/mpegurl":                 playlistfile.M3U,
+	"application/x-mpegurl":         playlistfile.M3U8,
+	"application/vnd.apple.mpegurl": playlistfile.M3U8,
+	"application/xspf+xml":          playlistfile.XSPF,
+}
+
+// playlistFormat reads the format query parameter, falling back to the
+// Content-Type of the request when fromBody is set.
+func playlistFormat(r *http.Request, fromBody bool) (playlistfile.Format, bool) {
+	format := playlistfile.Format(r.URL.Query().Get("format"))
+	switch format {
+	case playlistfile.M3U, playlistfile.M3U8, playlistfile.XSPF:
+		return format, true
+	case "":
+		if fromBody {
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			format, ok := playlistMediaTypes[mediaType]
+			return format, ok
+		}
+		return playlistfile.M3U8, true
+	}
+	return "", false
+}
+
+// ImportPlaylist godoc
+// @Summary Import playlist file
+// @Description Create a playlist from an M3U, M3U8 (#EXTINF artist - title) or XSPF file. Tracks are matched to songs by group and title; unmatched tracks are reported and left out. The format comes from the format parameter or the Content-Type (audio/x-mpegurl, application/vnd.apple.mpegurl, application/xspf+xml).
+// @Tags playlists
+// @Accept plain
+// @Produce json
+// @Param format query string false "m3u, m3u8 or xspf" Enums(m3u, m3u8, xspf)
+// @Param name query string false "Playlist name (default: the title in the file)"
+// @Param file body string true "Playlist file"
+// @Success 201 {object} model.PlaylistImport
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /playlists/import [post]
+func (c *PlaylistController) ImportPlaylist(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling POST playlist import request", logrus.Fields{})
+
+	format, ok := playlistFormat(r, true)
+	if !ok {
+		c.log.Error("Unsupported playlist format", logrus.Fields{"format": r.URL.Query().Get("format"), "content_type": r.Header.Get("Content-Type")})
+		http.Error(w, "format must be m3u, m3u8 or xspf", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxPlaylistFileSize)
+	report, err := c.service.ImportPlaylist(body, format, r.URL.Query().Get("name"))
+	if err != nil {
+		c.log.Error("Failed to import playlist", logrus.Fields{"error": err})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Playlist file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), playlistErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// ExportPlaylist godoc
+// @Summary Export playlist file
+// @Description Download a playlist as M3U, M3U8 or XSPF with the YouTube link of each song as its location. M3U files leave out songs without a link.
+// @Tags playlists
+// @Produce plain
+// @Param id path int true "Playlist ID"
+// @Param format query string false "m3u, m3u8 (default) or xspf" Enums(m3u, m3u8, xspf)
+// @Success 200 {string} string
+// @Header 200 {string} Content-Disposition "attachment; filename=playlist-<id>.<format>"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /playlists/{id}/export [get]
+func (c *PlaylistController) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid playlist ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid playlist ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling GET playlist export request", logrus.Fields{"playlist_id": id})
+
+	format, ok := playlistFormat(r, false)
+	if !ok {
+		http.Error(w, "format must be m3u, m3u8 or xspf", http.StatusBadRequest)
+		return
+	}
+
+	out := &exportWriter{w: w, headers: func(h http.Header) {
+		h.Set("Content-Type", format.ContentType())
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, id, format))
+	}}
+	if err := c.service.ExportPlaylist(out, id, format); err != nil {
+		c.log.Error("Failed to export playlist", logrus.Fields{"error": err, "playlist_id": id})
+		if !out.started {
+			http.Error(w, err.Error(), playlistErrorStatus(err))
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// revisionVars reads the song id and, when present, the revision number of
+// a revisions route. ok is false when the request has been answered.
+func (c *MainController) revisionVars(w http.ResponseWriter, r *http.Request) (id, rev int, ok bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		c.log.Error("Invalid song ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	if value, exists := vars["rev"]; exists {
+		rev, err = strconv.Atoi(value)
+		if err != nil || rev < 1 {
+			c.log.Error("Invalid revision", logrus.Fields{"revision": value})
+			http.Error(w, "Invalid revision", http.StatusBadRequest)
+			return 0, 0, false
+		}
+	}
+	return id, rev, true
+}
+
+// GetRevisions godoc
+// @Summary List song revisions
+// @Description List the recorded changes of a song, newest first. Each revision holds the song as it was after the change, who made it and when.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.SongRevision
+// @Failure 400 {object} map[string]string
+// @Router /songs/{id}/revisions [get]
+func (c *MainController) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id, _, ok := c.revisionVars(w, r)
+	if !ok {
+		return
+	}
+
+	c.log.Info("Handling GET song revisions request", logrus.Fields{"song_id": id})
+
+	limit, offset := pagination(r)
+
+	revisions, err := c.service.GetRevisions(id, limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get revisions", logrus.Fields{"error": err, "song_id": id})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetRevision godoc
+// @Summary Get song revision
+// @Description Get one recorded revision of a song
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} model.SongRevision
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /songs/{id}/revisions/{rev} [get]
+func (c *MainController) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id, rev, ok := c.revisionVars(w, r)
+	if !ok {
+		return
+	}
+
+	c.log.Info("Handling GET song revision request", logrus.Fields{"song_id": id, "revision": rev})
+
+	revision, err := c.service.GetRevision(id, rev)
+	if err != nil {
+		c.log.Error("Failed to get revision", logrus.Fields{"error": err, "song_id": id, "revision": rev})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(revision); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// RevertSong godoc
+// @Summary Revert song to a revision
+// @Description Restore the fields of a song from one of its revisions. The revert is recorded as a new revision. Trashed songs must be restored first.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Param If-Match header string false "ETag from GET /songs/{id}; the write applies only to that version"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string "The song changed since the given ETag"
+// @Failure 428 {object} map[string]string "If-Match is missing and REQUIRE_IF_MATCH is set"
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /songs/{id}/revisions/{rev}/revert [post]
+func (c *MainController) RevertSong(w http.ResponseWriter, r *http.Request) {
+	id, rev, ok := c.revisionVars(w, r)
+	if !ok {
+		return
+	}
+
+	c.log.Info("Handling POST song revert request", logrus.Fields{"song_id": id, "revision": rev})
+
+	version, ok := c.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := c.service.RevertSong(id, rev, version, principal(r)); err != nil {
+		c.log.Error("Failed to revert song", logrus.Fields{"error": err, "song_id": id, "revision": rev})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// DiffLyrics godoc
+// @Summary Diff lyrics between revisions
+// @Description Compare the lyrics of two revisions line by line. Hunks never cross a verse break ("\n\n"). format=unified returns only the unified diff as text.
+// @Tags revisions
+// @Produce json
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Param from query int true "Old revision"
+// @Param to query int false "New revision (default latest)"
+// @Param format query string false "json (default) or unified"
+// @Success 200 {object} model.LyricsDiff
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string "Lyrics too long to compare"
+// @Router /songs/{id}/lyrics/diff [get]
+func (c *MainController) DiffLyrics(w http.ResponseWriter, r *http.Request) {
+	id, _, ok := c.revisionVars(w, r)
+	if !ok {
+		return
+	}
+
+	c.log.Info("Handling GET lyrics diff request", logrus.Fields{"song_id": id})
+
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil || from < 1 {
+		http.Error(w, "Invalid from revision", http.StatusBadRequest)
+		return
+	}
+	to := 0
+	if value := query.Get("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil || to < 1 {
+			http.Error(w, "Invalid to revision", http.StatusBadRequest)
+			return
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "unified" {
+		http.Error(w, "format must be json or unified", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := c.service.DiffLyrics(id, from, to)
+	if err != nil {
+		c.log.Error("Failed to diff lyrics", logrus.Fields{"error": err, "song_id": id, "from": from, "to": to})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	if format == "unified" {
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		if _, err := w.Write([]byte(diff.Unified)); err != nil {
+			c.log.Error("Failed to write response", logrus.Fields{"error": err})
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
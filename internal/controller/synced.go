@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"music/pkg/lrc"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// maxLRCSize bounds the LRC document accepted by PUT /songs/{id}/lyrics/synced.
+const maxLRCSize = 1 << 20
+
+// GetSyncedLyrics godoc
+// @Summary Get synced lyrics
+// @Description Get the time-synced lyrics of a song in playback order. With at, return the line being sung at that moment and the line after it instead. With format=lrc, download the lyrics as an LRC file.
+// @Tags lyrics
+// @Produce json
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Param at query string false "Playback position, mm:ss or mm:ss.xx"
+// @Param format query string false "json (default) or lrc" Enums(json, lrc)
+// @Success 200 {array} model.SyncedLine
+// @Success 200 {object} model.SyncedLyricsAt "With at"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string "No such song, or it has no synced lyrics"
+// @Router /songs/{id}/lyrics/synced [get]
+func (c *MainController) GetSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid song ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling GET synced lyrics request", logrus.Fields{"song_id": id})
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "lrc" {
+		http.Error(w, "format must be json or lrc", http.StatusBadRequest)
+		return
+	}
+
+	if format == "lrc" {
+		out := &exportWriter{w: w, headers: func(h http.Header) {
+			h.Set("Content-Type", "text/plain; charset=utf-8")
+			h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="song-%d.lrc"`, id))
+		}}
+		if err := c.service.ExportSyncedLyrics(id, out); err != nil {
+			c.log.Error("Failed to export synced lyrics", logrus.Fields{"error": err, "song_id": id})
+			if !out.started {
+				http.Error(w, err.Error(), songErrorStatus(err))
+			}
+		}
+		return
+	}
+
+	var response interface{}
+	if value := query.Get("at"); value != "" {
+		at, err := lrc.ParseTimestamp(value)
+		if err != nil {
+			c.log.Error("Invalid playback position", logrus.Fields{"at": value})
+			http.Error(w, "at: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		response, err = c.service.SyncedLyricsAt(id, at)
+		if err != nil {
+			c.log.Error("Failed to get synced lyrics", logrus.Fields{"error": err, "song_id": id})
+			http.Error(w, err.Error(), songErrorStatus(err))
+			return
+		}
+	} else {
+		response, err = c.service.GetSyncedLyrics(id)
+		if err != nil {
+			c.log.Error("Failed to get synced lyrics", logrus.Fields{"error": err, "song_id": id})
+			http.Error(w, err.Error(), songErrorStatus(err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// SetSyncedLyrics godoc
+// @Summary Set synced lyrics
+// @Description Replace the time-synced lyrics of a song with an LRC document: "[mm:ss.xx]text" lines, optionally preceded by tags such as [ti:...] and [offset:...]. A line may carry several timestamps when it repeats. Otherwise every line must start later than the one before it.
+// @Tags lyrics
+// @Accept plain
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param lyrics body string true "LRC document"
+// @Success 200 {object} map[string]interface{} "status and the number of lines stored"
+// @Failure 400 {object} map[string]string "Malformed LRC"
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} map[string]string "Timestamps out of order"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /songs/{id}/lyrics/synced [put]
+func (c *MainController) SetSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid song ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling PUT synced lyrics request", logrus.Fields{"song_id": id})
+
+	lines, err := c.service.SetSyncedLyrics(id, http.MaxBytesReader(w, r.Body, maxLRCSize))
+	if err != nil {
+		c.log.Error("Failed to set synced lyrics", logrus.Fields{"error": err, "song_id": id})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "LRC document is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "lines": lines}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// DeleteSyncedLyrics godoc
+// @Summary Delete synced lyrics
+// @Description Remove the time-synced lyrics of a song. The plain lyrics are kept.
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Router /songs/{id}/lyrics/synced [delete]
+func (c *MainController) DeleteSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid song ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		return
+	}
+
+	c.log.Info("Handling DELETE synced lyrics request", logrus.Fields{"song_id": id})
+
+	if err := c.service.DeleteSyncedLyrics(id); err != nil {
+		c.log.Error("Failed to delete synced lyrics", logrus.Fields{"error": err, "song_id": id})
+		http.Error(w, err.Error(), songErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
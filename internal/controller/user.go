@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/internal/services"
+	"music/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type UserController struct {
+	service *services.UserService
+	auth    *Authenticator
+	log     *logger.Logger
+	router  *mux.Router
+}
+
+func NewUserController(service *services.UserService, auth *Authenticator, m *mux.Router, log *logger.Logger) *UserController {
+	return &UserController{
+		service: service,
+		auth:    auth,
+		log:     log,
+		router:  m,
+	}
+}
+
+func (c *UserController) RegisterHandlers() {
+	c.router.Handle("/users", c.auth.Require(model.PermUsersManage, c.GetAllUsers)).Methods("GET")
+	c.router.Handle("/users/{id}", c.auth.Require(model.PermUsersManage, c.handleUserByID)).Methods("GET", "DELETE")
+	c.router.Handle("/users/{id}/role", c.auth.Require(model.PermUsersManage, c.handleUserByID)).Methods("PUT")
+}
+
+func (c *UserController) handleUserByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		c.log.Error("Invalid user ID", logrus.Fields{"error": err})
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c.GetUser(w, r, id)
+	case http.MethodPut:
+		c.SetUserRole(w, r, id)
+	case http.MethodDelete:
+		c.DeleteUser(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// userErrorStatus maps repository errors to HTTP status codes.
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrLastAdmin):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrInvalidRole):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetAllUsers godoc
+// @Summary Get all users
+// @Description List user accounts (admin only)
+// @Tags users
+// @Produce json
+// @Param limit query int false "Limit (default 10)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {array} model.User
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /users [get]
+func (c *UserController) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	c.log.Info("Handling GET all users request", logrus.Fields{})
+
+	limit, offset := pagination(r)
+
+	users, err := c.service.GetAllUsers(limit, offset)
+	if err != nil {
+		c.log.Error("Failed to get users", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(users); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetUser godoc
+// @Summary Get user by ID
+// @Description Get a user account (admin only)
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} model.User
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /users/{id} [get]
+func (c *UserController) GetUser(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling GET user request", logrus.Fields{"user_id": id})
+
+	user, err := c.service.GetUserByID(id)
+	if err != nil {
+		c.log.Error("Failed to get user", logrus.Fields{"error": err, "user_id": id})
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// SetUserRole godoc
+// @Summary Change user role
+// @Description Set the role of a user to viewer, editor or admin (admin only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body model.RoleRequest true "New role"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /users/{id}/role [put]
+func (c *UserController) SetUserRole(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling PUT user role request", logrus.Fields{"user_id": id})
+
+	var req model.RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.log.Error("Failed to decode request body", logrus.Fields{"error": err})
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.SetUserRole(id, req.Role); err != nil {
+		c.log.Error("Failed to change user role", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
+
+// DeleteUser godoc
+// @Summary Delete user
+// @Description Delete a user account (admin only); the last admin cannot be deleted
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} model.AccessError
+// @Failure 403 {object} model.AccessError
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /users/{id} [delete]
+func (c *UserController) DeleteUser(w http.ResponseWriter, r *http.Request, id int) {
+	c.log.Info("Handling DELETE user request", logrus.Fields{"user_id": id})
+
+	if err := c.service.DeleteUser(id); err != nil {
+		c.log.Error("Failed to delete user", logrus.Fields{"error": err})
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		c.log.Error("Failed to encode response", logrus.Fields{"error": err})
+	}
+}
@@ -8,12 +8,31 @@ import (
 	_ "github.com/lib/pq"
 )
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 type DB struct {
 	PostgreSQL *sql.DB
+	SQLite     *sql.DB
+	Driver     string
+}
+
+// Open connects to the storage backend selected by cfg.DB.Driver.
+func Open(cfg *config.Config) (*DB, error) {
+	switch cfg.DB.Driver {
+	case DriverPostgres:
+		return NewDB(cfg)
+	case DriverSQLite:
+		return NewSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q", cfg.DB.Driver)
+	}
 }
 
 func NewDB(cfg *config.Config) (*DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", 
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DB.Host,
 		cfg.DB.Port,
 		cfg.DB.User,
@@ -27,12 +46,23 @@ func NewDB(cfg *config.Config) (*DB, error) {
 	if err = db.Ping(); err != nil {
 		return nil, err
 	}
-	return &DB{PostgreSQL: db}, nil
+	return &DB{PostgreSQL: db, Driver: DriverPostgres}, nil
+}
+
+// Conn returns the connection pool of the active backend.
+func (db *DB) Conn() *sql.DB {
+	if db.Driver == DriverSQLite {
+		return db.SQLite
+	}
+	return db.PostgreSQL
 }
 
 func (db *DB) Close() error {
 	if db.PostgreSQL != nil {
 		return db.PostgreSQL.Close()
 	}
+	if db.SQLite != nil {
+		return db.SQLite.Close()
+	}
 	return nil
 }
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"io/fs"
+
+	"music/migrations"
+
+	"github.com/pressly/goose/v3"
+)
+
+func (db *DB) migrator() (*goose.Provider, error) {
+	if db.Driver == DriverSQLite {
+		fsys, err := fs.Sub(migrations.FS, "sqlite")
+		if err != nil {
+			return nil, err
+		}
+		return goose.NewProvider(goose.DialectSQLite3, db.SQLite, fsys)
+	}
+	return goose.NewProvider(goose.DialectPostgres, db.PostgreSQL, migrations.FS)
+}
+
+// MigrateUp applies all pending embedded migrations.
+func (db *DB) MigrateUp(ctx context.Context) ([]*goose.MigrationResult, error) {
+	provider, err := db.migrator()
+	if err != nil {
+		return nil, err
+	}
+	return provider.Up(ctx)
+}
+
+// MigrateDown rolls back the most recently applied migration.
+func (db *DB) MigrateDown(ctx context.Context) (*goose.MigrationResult, error) {
+	provider, err := db.migrator()
+	if err != nil {
+		return nil, err
+	}
+	return provider.Down(ctx)
+}
+
+// MigrationStatus reports every embedded migration and whether it is applied.
+func (db *DB) MigrationStatus(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	provider, err := db.migrator()
+	if err != nil {
+		return nil, err
+	}
+	return provider.Status(ctx)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"music/pkg/config"
+	"music/pkg/trigram"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// SQLite's lower() only folds ASCII; replace it so that case-insensitive
+	// filters behave like PostgreSQL for any alphabet.
+	sqlite.MustRegisterDeterministicScalarFunction("lower", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if s, ok := args[0].(string); ok {
+			return strings.ToLower(s), nil
+		}
+		return args[0], nil
+	})
+	// squeeze_spaces trims a string and collapses its inner runs of
+	// whitespace to one space, like REGEXP_REPLACE(BTRIM(s), '\s+', ' ', 'g')
+	// in PostgreSQL.
+	sqlite.MustRegisterDeterministicScalarFunction("squeeze_spaces", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if s, ok := args[0].(string); ok {
+			return strings.Join(strings.Fields(s), " "), nil
+		}
+		return args[0], nil
+	})
+	// similarity mirrors pg_trgm's function of the same name.
+	sqlite.MustRegisterDeterministicScalarFunction("similarity", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		a, _ := args[0].(string)
+		b, _ := args[1].(string)
+		return trigram.Similarity(a, b), nil
+	})
+}
+
+// NewSQLite opens the SQLite database file at cfg.DB.Path, creating it if
+// it does not exist yet.
+func NewSQLite(cfg *config.Config) (*DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite",
+		cfg.DB.Path,
+	)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+	return &DB{SQLite: db, Driver: DriverSQLite}, nil
+}
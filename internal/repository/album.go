@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"music/internal/model"
+)
+
+var (
+	ErrAlbumNotFound = errors.New("album not found")
+	ErrAlbumTitle    = errors.New("album title is required")
+	ErrAlbumArtist   = errors.New("album artist is required")
+	ErrTrackNumber   = errors.New("track and disc numbers must be positive")
+	ErrTrackTaken    = errors.New("track position is already taken on this album")
+)
+
+type AlbumRepository struct {
+	db conn
+}
+
+func NewAlbumRepository(db *sql.DB, driver string) *AlbumRepository {
+	return &AlbumRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+const albumColumns = `al.id, al.title, a.name, al.artist_id, COALESCE(al.release_date, ''), al.created_at`
+
+const albumsFrom = `FROM albums al JOIN artists a ON a.id = al.artist_id`
+
+func scanAlbum(row scanner) (model.Album, error) {
+	var album model.Album
+	err := row.Scan(
+		&album.ID,
+		&album.Title,
+		&album.GroupName,
+		&album.ArtistID,
+		&album.ReleaseDate,
+		&album.CreatedAt,
+	)
+	return album, err
+}
+
+// normalizeTrack clears the track position of songs without an album and
+// defaults the disc number to 1.
+func normalizeTrack(song *model.Song) error {
+	if song.AlbumID == 0 {
+		song.DiscNumber, song.TrackNumber = 0, 0
+		return nil
+	}
+	if song.DiscNumber < 0 || song.TrackNumber < 0 {
+		return ErrTrackNumber
+	}
+	if song.TrackNumber != 0 && song.DiscNumber == 0 {
+		song.DiscNumber = 1
+	}
+	return nil
+}
+
+// checkTrack verifies that the album of song exists and that its track
+// position is not used by another song.
+func checkTrack(c conn, song model.Song, songID int) error {
+	if song.AlbumID == 0 {
+		return nil
+	}
+
+	var count int
+	if err := c.queryRow(`SELECT COUNT(*) FROM albums WHERE id = $1`, song.AlbumID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrAlbumNotFound
+	}
+
+	if song.TrackNumber == 0 {
+		return nil
+	}
+	query := `
+        SELECT COUNT(*) FROM songs
+        WHERE album_id = $1 AND disc_number = $2 AND track_number = $3 AND id <> $4
+            AND deleted_at IS NULL
+    `
+	if err := c.queryRow(query, song.AlbumID, song.DiscNumber, song.TrackNumber, songID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTrackTaken
+	}
+	return nil
+}
+
+// albumArtist resolves the artist of an album either by name or by id.
+func albumArtist(c conn, album model.Album) (int, error) {
+	if album.GroupName != "" {
+		return resolveArtist(c, album.GroupName)
+	}
+	if album.ArtistID == 0 {
+		return 0, ErrAlbumArtist
+	}
+
+	var count int
+	if err := c.queryRow(`SELECT COUNT(*) FROM artists WHERE id = $1`, album.ArtistID).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrArtistNotFound
+	}
+	return album.ArtistID, nil
+}
+
+func (r *AlbumRepository) GetAllAlbums(filters map[string]string, limit, offset int) ([]model.Album, error) {
+	var albums []model.Album
+
+	query := `SELECT ` + albumColumns + ` ` + albumsFrom + ` WHERE 1=1`
+	args := make([]interface{}, 0)
+	paramCounter := 1
+
+	if value := filters["artist"]; value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid artist filter: %w", err)
+		}
+		query += fmt.Sprintf(" AND al.artist_id = $%d", paramCounter)
+		args = append(args, id)
+		paramCounter++
+	}
+	if value := filters["group"]; value != "" {
+		query += fmt.Sprintf(" AND a.normalized_name = $%d", paramCounter)
+		args = append(args, model.NormalizeArtistName(value))
+		paramCounter++
+	}
+	if value := filters["title"]; value != "" {
+		query += fmt.Sprintf(" AND al.title = $%d", paramCounter)
+		args = append(args, value)
+		paramCounter++
+	}
+
+	query += fmt.Sprintf(" ORDER BY al.id LIMIT $%d OFFSET $%d", paramCounter, paramCounter+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		albums = append(albums, album)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return albums, nil
+}
+
+// GetAlbumByID returns the album together with its track list.
+func (r *AlbumRepository) GetAlbumByID(id int) (model.Album, error) {
+	album, err := scanAlbum(r.db.queryRow(`SELECT `+albumColumns+` `+albumsFrom+` WHERE al.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return album, ErrAlbumNotFound
+		}
+		return album, err
+	}
+
+	tracks, err := r.GetAlbumTracks(id)
+	if err != nil {
+		return album, err
+	}
+	album.Tracks = make([]model.AlbumTrack, 0, len(tracks))
+	for _, song := range tracks {
+		album.Tracks = append(album.Tracks, model.AlbumTrack{
+			SongID:      song.ID,
+			DiscNumber:  song.DiscNumber,
+			TrackNumber: song.TrackNumber,
+		})
+	}
+
+	return album, nil
+}
+
+// GetAlbumTracks returns the songs of an album in disc and track order.
+// Songs without a track number come last.
+func (r *AlbumRepository) GetAlbumTracks(id int) ([]model.Song, error) {
+	var songs []model.Song
+
+	query := `SELECT ` + songColumns + ` ` + songsFrom + `
+        WHERE s.album_id = $1 AND s.deleted_at IS NULL
+        ORDER BY COALESCE(s.disc_number, 1), CASE WHEN s.track_number IS NULL THEN 1 ELSE 0 END, s.track_number, s.id`
+
+	rows, err := r.db.query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		songs = append(songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return songs, nil
+}
+
+// AddAlbum stores the album and places its tracks. Every song that gets a
+// track position records change as a revision.
+func (r *AlbumRepository) AddAlbum(album model.Album, change model.SongRevision) (int, error) {
+	if album.Title == "" {
+		return 0, ErrAlbumTitle
+	}
+
+	var id int
+	err := r.db.withTx(func(tx conn) error {
+		artistID, err := albumArtist(tx, album)
+		if err != nil {
+			return err
+		}
+		query := `
+        INSERT INTO albums (title, artist_id, release_date, created_at)
+        VALUES ($1, $2, $3, $4)
+    `
+		id, err = tx.insert(query, album.Title, artistID, album.ReleaseDate, time.Now())
+		if err != nil {
+			return err
+		}
+		if album.Tracks != nil {
+			return setTracks(tx, id, album.Tracks, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateAlbum overwrites the album fields. The track list is replaced only
+// when album.Tracks is non-nil; an empty list removes all tracks. Every song
+// whose track position changes records change as a revision.
+func (r *AlbumRepository) UpdateAlbum(id int, album model.Album, change model.SongRevision) error {
+	if album.Title == "" {
+		return ErrAlbumTitle
+	}
+
+	return r.db.withTx(func(tx conn) error {
+		artistID, err := albumArtist(tx, album)
+		if err != nil {
+			return err
+		}
+		query := `
+        UPDATE albums
+        SET title = $1, artist_id = $2, release_date = $3
+        WHERE id = $4
+    `
+		res, err := tx.exec(query, album.Title, artistID, album.ReleaseDate, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrAlbumNotFound
+		}
+		if album.Tracks != nil {
+			return setTracks(tx, id, album.Tracks, change)
+		}
+		return nil
+	})
+}
+
+// setTracks replaces the track list of an album. Songs that keep their
+// position are left alone; the others are versioned like any song update.
+func setTracks(c conn, albumID int, tracks []model.AlbumTrack, change model.SongRevision) error {
+	type position struct{ disc, track int }
+	seen := make(map[position]bool, len(tracks))
+	for i := range tracks {
+		if tracks[i].DiscNumber == 0 {
+			tracks[i].DiscNumber = 1
+		}
+		if tracks[i].DiscNumber < 0 || tracks[i].TrackNumber <= 0 {
+			return ErrTrackNumber
+		}
+		p := position{tracks[i].DiscNumber, tracks[i].TrackNumber}
+		if seen[p] {
+			return ErrTrackTaken
+		}
+		seen[p] = true
+	}
+
+	// current holds the positions of the live songs on the album now;
+	// trashed songs hold no position and are always detached.
+	current := make(map[int]position)
+	var trashed []int
+	rows, err := c.query(`SELECT id, disc_number, track_number, deleted_at IS NULL FROM songs WHERE album_id = $1`, albumID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			id   int
+			disc sql.NullInt64
+			num  sql.NullInt64
+			live bool
+		)
+		if err := rows.Scan(&id, &disc, &num, &live); err != nil {
+			rows.Close()
+			return err
+		}
+		if live {
+			current[id] = position{int(disc.Int64), int(num.Int64)}
+		} else {
+			trashed = append(trashed, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	wanted := make(map[int]position, len(tracks))
+	for _, t := range tracks {
+		wanted[t.SongID] = position{t.DiscNumber, t.TrackNumber}
+	}
+
+	// Free the positions that change hands first, so that two songs can
+	// swap places.
+	changed := trashed
+	detach := `UPDATE songs SET album_id = NULL, disc_number = NULL, track_number = NULL WHERE id = $1`
+	for _, id := range trashed {
+		if _, err := c.exec(detach, id); err != nil {
+			return err
+		}
+	}
+	for id, p := range current {
+		if w, ok := wanted[id]; ok && w == p {
+			continue
+		}
+		if _, err := c.exec(detach, id); err != nil {
+			return err
+		}
+		if _, ok := wanted[id]; !ok {
+			changed = append(changed, id)
+		}
+	}
+
+	for _, t := range tracks {
+		if p, ok := current[t.SongID]; ok && p == wanted[t.SongID] {
+			continue
+		}
+		query := `UPDATE songs SET album_id = $1, disc_number = $2, track_number = $3 WHERE id = $4 AND deleted_at IS NULL`
+		res, err := c.exec(query, albumID, t.DiscNumber, t.TrackNumber, t.SongID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("track %d/%d: %w", t.DiscNumber, t.TrackNumber, ErrSongNotFound)
+		}
+		changed = append(changed, t.SongID)
+	}
+
+	sort.Ints(changed)
+	for i, id := range changed {
+		if i > 0 && id == changed[i-1] {
+			continue
+		}
+		if err := touchSong(c, id, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAlbum removes the album; its songs are kept and detached, each
+// recording change as a revision.
+func (r *AlbumRepository) DeleteAlbum(id int, change model.SongRevision) error {
+	return r.db.withTx(func(tx conn) error {
+		if err := setTracks(tx, id, []model.AlbumTrack{}, change); err != nil {
+			return err
+		}
+		res, err := tx.exec(`DELETE FROM albums WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrAlbumNotFound
+		}
+		return nil
+	})
+}
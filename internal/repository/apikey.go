@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"music/internal/model"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyRepository struct {
+	db conn
+}
+
+func NewAPIKeyRepository(db *sql.DB, driver string) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, COALESCE(created_by, 0), created_at, last_used_at, revoked_at`
+
+func scanAPIKey(row scanner) (model.APIKey, error) {
+	var (
+		key      model.APIKey
+		scopes   string
+		lastUsed sql.NullTime
+		revoked  sql.NullTime
+	)
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&lastUsed,
+		&revoked,
+	)
+	if err == sql.ErrNoRows {
+		return key, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return key, err
+	}
+
+	key.Scopes = make([]model.Permission, 0)
+	for _, scope := range strings.Fields(scopes) {
+		key.Scopes = append(key.Scopes, model.Permission(scope))
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		key.RevokedAt = &revoked.Time
+	}
+	return key, nil
+}
+
+// AddKey stores a key under the hash of its secret.
+func (r *APIKeyRepository) AddKey(key model.APIKey, hash string) (int, error) {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	query := `
+        INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+	return r.db.insert(query, key.Name, key.Prefix, hash, strings.Join(scopes, " "), nullInt(key.CreatedBy), time.Now())
+}
+
+func (r *APIKeyRepository) GetAllKeys(limit, offset int) ([]model.APIKey, error) {
+	var keys []model.APIKey
+
+	rows, err := r.db.query(`SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) GetKeyByID(id int) (model.APIKey, error) {
+	return scanAPIKey(r.db.queryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+}
+
+// FindActiveKey returns the unrevoked key with the given hash and records
+// that it was used.
+func (r *APIKeyRepository) FindActiveKey(hash string) (model.APIKey, error) {
+	key, err := scanAPIKey(r.db.queryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`, hash))
+	if err != nil {
+		return key, err
+	}
+
+	now := time.Now()
+	if _, err := r.db.exec(`UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, now, key.ID); err != nil {
+		return key, err
+	}
+	key.LastUsedAt = &now
+	return key, nil
+}
+
+// RevokeKey disables a key; revoked keys stay listed.
+func (r *APIKeyRepository) RevokeKey(id int) error {
+	res, err := r.db.exec(`UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"music/internal/model"
+)
+
+var (
+	ErrArtistNotFound = errors.New("artist not found")
+	ErrArtistExists   = errors.New("artist with this name already exists")
+	ErrArtistHasSongs = errors.New("artist still has songs")
+	ErrArtistName     = errors.New("artist name is required")
+)
+
+type ArtistRepository struct {
+	db conn
+}
+
+func NewArtistRepository(db *sql.DB, driver string) *ArtistRepository {
+	return &ArtistRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+const artistColumns = `id, name, COALESCE(country, ''), COALESCE(description, ''), created_at`
+
+func scanArtist(row scanner) (model.Artist, error) {
+	var artist model.Artist
+	err := row.Scan(
+		&artist.ID,
+		&artist.Name,
+		&artist.Country,
+		&artist.Description,
+		&artist.CreatedAt,
+	)
+	return artist, err
+}
+
+// resolveArtist returns the id of the artist whose normalized name matches
+// name, creating the artist if needed.
+func resolveArtist(c conn, name string) (int, error) {
+	normalized := model.NormalizeArtistName(name)
+	if normalized == "" {
+		return 0, ErrArtistName
+	}
+
+	var id int
+	err := c.queryRow(`SELECT id FROM artists WHERE normalized_name = $1`, normalized).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("resolve artist: %w", err)
+	}
+
+	query := `
+        INSERT INTO artists (name, normalized_name, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (normalized_name) DO NOTHING
+    `
+	if _, err := c.exec(query, model.CleanArtistName(name), normalized, time.Now()); err != nil {
+		return 0, fmt.Errorf("resolve artist: %w", err)
+	}
+
+	if err := c.queryRow(`SELECT id FROM artists WHERE normalized_name = $1`, normalized).Scan(&id); err != nil {
+		return 0, fmt.Errorf("resolve artist: %w", err)
+	}
+	return id, nil
+}
+
+func (r *ArtistRepository) GetAllArtists(name string, limit, offset int) ([]model.Artist, error) {
+	var artists []model.Artist
+
+	query := `SELECT ` + artistColumns + ` FROM artists WHERE 1=1`
+	args := make([]interface{}, 0)
+	paramCounter := 1
+
+	if name != "" {
+		query += fmt.Sprintf(" AND normalized_name = $%d", paramCounter)
+		args = append(args, model.NormalizeArtistName(name))
+		paramCounter++
+	}
+
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", paramCounter, paramCounter+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		artist, err := scanArtist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		artists = append(artists, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return artists, nil
+}
+
+func (r *ArtistRepository) GetArtistByID(id int) (model.Artist, error) {
+	artist, err := scanArtist(r.db.queryRow(`SELECT `+artistColumns+` FROM artists WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return artist, ErrArtistNotFound
+		}
+		return artist, err
+	}
+	return artist, nil
+}
+
+// nameTaken reports whether another artist than id already uses the
+// normalized form of name.
+func nameTaken(c conn, name string, id int) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM artists WHERE normalized_name = $1 AND id <> $2`
+	if err := c.queryRow(query, model.NormalizeArtistName(name), id).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *ArtistRepository) AddArtist(artist model.Artist) (int, error) {
+	if model.NormalizeArtistName(artist.Name) == "" {
+		return 0, ErrArtistName
+	}
+
+	var id int
+	err := r.db.withTx(func(tx conn) error {
+		taken, err := nameTaken(tx, artist.Name, 0)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrArtistExists
+		}
+		query := `
+        INSERT INTO artists (name, normalized_name, country, description, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+		id, err = tx.insert(query,
+			model.CleanArtistName(artist.Name),
+			model.NormalizeArtistName(artist.Name),
+			artist.Country,
+			artist.Description,
+			time.Now(),
+		)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *ArtistRepository) UpdateArtist(id int, artist model.Artist) error {
+	if model.NormalizeArtistName(artist.Name) == "" {
+		return ErrArtistName
+	}
+
+	return r.db.withTx(func(tx conn) error {
+		taken, err := nameTaken(tx, artist.Name, id)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrArtistExists
+		}
+		query := `
+        UPDATE artists
+        SET name = $1, normalized_name = $2, country = $3, description = $4
+        WHERE id = $5
+    `
+		res, err := tx.exec(query,
+			model.CleanArtistName(artist.Name),
+			model.NormalizeArtistName(artist.Name),
+			artist.Country,
+			artist.Description,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrArtistNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteArtist removes an artist that no longer has any songs.
+func (r *ArtistRepository) DeleteArtist(id int) error {
+	return r.db.withTx(func(tx conn) error {
+		var count int
+		if err := tx.queryRow(`SELECT COUNT(*) FROM songs WHERE artist_id = $1`, id).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrArtistHasSongs
+		}
+		res, err := tx.exec(`DELETE FROM artists WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrArtistNotFound
+		}
+		return nil
+	})
+}
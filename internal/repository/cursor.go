@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"music/internal/model"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// sortKey is one column of the song list order. Keyset pagination compares
+// the same expressions against the values of the last song of a page.
+type sortKey struct {
+	name  string
+	expr  string
+	desc  bool
+	value func(model.Song) interface{}
+	parse func(string) (interface{}, error)
+}
+
+var idKey = sortKey{
+	name:  "id",
+	expr:  "s.id",
+	value: func(s model.Song) interface{} { return s.ID },
+	parse: func(v string) (interface{}, error) { return strconv.Atoi(v) },
+}
+
+// similarityKey orders fuzzy matches; its expression is the score built by
+// songWhere.
+var similarityKey = sortKey{
+	name: "similarity",
+	desc: true,
+	value: func(s model.Song) interface{} {
+		if s.Similarity == nil {
+			return float64(0)
+		}
+		return *s.Similarity
+	},
+	parse: func(v string) (interface{}, error) { return strconv.ParseFloat(v, 64) },
+}
+
+// usesFuzzy reports whether a fuzzy filter with a value is set.
+func usesFuzzy(q model.SongQuery) bool {
+	for param, mode := range q.Match {
+		if mode == model.MatchFuzzy && q.Filters[param] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortKeys are the keys accepted by SongQuery.Sort.
+var sortKeys = map[string]sortKey{
+	"group": {
+		expr:  "a.normalized_name",
+		value: func(s model.Song) interface{} { return model.NormalizeArtistName(s.GroupName) },
+		parse: func(v string) (interface{}, error) { return v, nil },
+	},
+	"song": {
+		expr:  "LOWER(s.song_title)",
+		value: func(s model.Song) interface{} { return strings.ToLower(s.SongTitle) },
+		parse: func(v string) (interface{}, error) { return v, nil },
+	},
+	"release_date": {
+		expr:  "COALESCE(s.release_date, '" + noRelease + "')",
+		value: func(s model.Song) interface{} { return releaseISO(s) },
+		parse: func(v string) (interface{}, error) { return v, nil },
+	},
+	"created_at": {
+		expr:  "s.created_at",
+		value: func(s model.Song) interface{} { return s.CreatedAt },
+		parse: func(v string) (interface{}, error) { return time.Parse(time.RFC3339Nano, v) },
+	},
+	"id": idKey,
+}
+
+// songOrder returns the sort keys of q, always ending with id so that the
+// order is total. Without an explicit sort, fuzzy matches come best first
+// and other lists are ordered by id.
+func songOrder(q model.SongQuery) ([]sortKey, error) {
+	if len(q.Sort) == 0 {
+		if usesFuzzy(q) {
+			return []sortKey{similarityKey, idKey}, nil
+		}
+		return []sortKey{idKey}, nil
+	}
+
+	var keys []sortKey
+	for _, field := range q.Sort {
+		key, ok := sortKeys[field.Key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown sort key %q", ErrInvalidSort, field.Key)
+		}
+		key.name = field.Key
+		key.desc = field.Desc
+		keys = append(keys, key)
+		if field.Key == idKey.name {
+			return keys, nil
+		}
+	}
+	return append(keys, idKey), nil
+}
+
+// orderSignature names an order; a cursor is only valid for the order it
+// was issued for.
+func orderSignature(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		dir := "asc"
+		if key.desc {
+			dir = "desc"
+		}
+		parts[i] = key.name + ":" + dir
+	}
+	return strings.Join(parts, ",")
+}
+
+type songCursor struct {
+	Order  string   `json:"o"`
+	Values []string `json:"v"`
+}
+
+// NextSongCursor returns the opaque cursor for the page after last.
+func NextSongCursor(q model.SongQuery, last model.Song) string {
+	keys, err := songOrder(q)
+	if err != nil {
+		return ""
+	}
+	cursor := songCursor{Order: orderSignature(keys)}
+	for _, key := range keys {
+		value := key.value(last)
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.RFC3339Nano)
+		}
+		cursor.Values = append(cursor.Values, fmt.Sprint(value))
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the sort key values stored in a cursor.
+func decodeCursor(token string, keys []sortKey) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor songCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Order != orderSignature(keys) || len(cursor.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		v, err := key.parse(cursor.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// keysetCondition returns the condition selecting rows after values in the
+// order of keys, using placeholders from $next.
+func keysetCondition(keys []sortKey, values []interface{}, next int) (string, []interface{}) {
+	var alternatives []string
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = $%d", keys[j].expr, next+j))
+		}
+		op := ">"
+		if key.desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s $%d", key.expr, op, next+i))
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", values
+}
+
+// orderBy renders keys as an ORDER BY list.
+func orderBy(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.expr
+		if key.desc {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareSongs compares two songs by keys for the in-memory repository.
+func compareSongs(keys []sortKey, a, b model.Song) int {
+	for _, key := range keys {
+		if c := compareValues(key.value(a), key.value(b)); c != 0 {
+			if key.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// afterCursor reports whether song sorts after the cursor values.
+func afterCursor(keys []sortKey, values []interface{}, song model.Song) bool {
+	for i, key := range keys {
+		c := compareValues(key.value(song), values[i])
+		if key.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c > 0
+		}
+	}
+	return false
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		return compareOrdered(a, b.(int))
+	case float64:
+		return compareOrdered(a, b.(float64))
+	case string:
+		return compareOrdered(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+func compareOrdered[T int | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
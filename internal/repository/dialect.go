@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+
+	"music/internal/db"
+)
+
+var placeholderRe = regexp.MustCompile(`\$(\d+)`)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// conn wraps a connection pool (or an open transaction) together with the
+// driver it talks to, so queries can be written once with PostgreSQL $N
+// placeholders.
+type conn struct {
+	db     queryer
+	driver string
+}
+
+// rebind rewrites $N placeholders into SQLite's numbered ?N form.
+func (c conn) rebind(query string) string {
+	if c.driver != db.DriverSQLite {
+		return query
+	}
+	return placeholderRe.ReplaceAllString(query, "?$1")
+}
+
+func (c conn) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.Query(c.rebind(query), args...)
+}
+
+func (c conn) queryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRow(c.rebind(query), args...)
+}
+
+func (c conn) exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.Exec(c.rebind(query), args...)
+}
+
+// insert runs an INSERT statement and returns the generated id, using
+// RETURNING on PostgreSQL and LastInsertId on SQLite.
+func (c conn) insert(query string, args ...interface{}) (int, error) {
+	if c.driver == db.DriverSQLite {
+		res, err := c.exec(query, args...)
+		if err != nil {
+			return 0, err
+		}
+		id, err := res.LastInsertId()
+		return int(id), err
+	}
+
+	var id int
+	if err := c.queryRow(query+" RETURNING id", args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// withTx runs fn inside a transaction, committing when it returns nil. When
+// c is already bound to a transaction fn joins it.
+func (c conn) withTx(fn func(tx conn) error) error {
+	pool, ok := c.db.(*sql.DB)
+	if !ok {
+		return fn(c)
+	}
+
+	tx, err := pool.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(conn{db: tx, driver: c.driver}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
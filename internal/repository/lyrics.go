@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"music/internal/model"
+)
+
+var ErrNoSyncedLyrics = errors.New("song has no synced lyrics")
+
+// LyricsStore keeps the time-synced lyrics of songs and the sections their
+// lyrics are made of for services.MainService.
+type LyricsStore interface {
+	// GetSyncedLyrics returns the synced lines of a live song in playback
+	// order, or ErrNoSyncedLyrics.
+	GetSyncedLyrics(songID int) ([]model.SyncedLine, error)
+	SetSyncedLyrics(songID int, lines []model.SyncedLine) error
+	DeleteSyncedLyrics(songID int) error
+	// GetSections returns the stored sections of a song and the song
+	// version they were taken from, 0 when none are stored.
+	GetSections(songID int) ([]model.LyricsSection, int, error)
+	SetSections(songID, version int, sections []model.LyricsSection) error
+}
+
+var (
+	_ LyricsStore = (*LyricsRepository)(nil)
+	_ LyricsStore = (*MemoryRepository)(nil)
+)
+
+// LyricsRepository stores the time-synced lyrics of songs and the sections
+// their lyrics are made of.
+type LyricsRepository struct {
+	db conn
+}
+
+func NewLyricsRepository(db *sql.DB, driver string) *LyricsRepository {
+	return &LyricsRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+// liveSong reports ErrSongNotFound unless the song exists outside the trash.
+func liveSong(c conn, id int) error {
+	var count int
+	if err := c.queryRow(`SELECT COUNT(*) FROM songs WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSongNotFound
+	}
+	return nil
+}
+
+// GetSyncedLyrics returns the synced lines of a song in playback order.
+func (r *LyricsRepository) GetSyncedLyrics(songID int) ([]model.SyncedLine, error) {
+	if err := liveSong(r.db, songID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.query(`SELECT time_ms, text FROM synced_lyrics WHERE song_id = $1 ORDER BY line`, songID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []model.SyncedLine
+	for rows.Next() {
+		var line model.SyncedLine
+		if err := rows.Scan(&line.TimeMs, &line.Text); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrNoSyncedLyrics
+	}
+	return lines, nil
+}
+
+// SetSyncedLyrics replaces the synced lines of a song. The lines must
+// already be in playback order.
+func (r *LyricsRepository) SetSyncedLyrics(songID int, lines []model.SyncedLine) error {
+	return r.db.withTx(func(tx conn) error {
+		if err := liveSong(tx, songID); err != nil {
+			return err
+		}
+		if _, err := tx.exec(`DELETE FROM synced_lyrics WHERE song_id = $1`, songID); err != nil {
+			return err
+		}
+		for i, line := range lines {
+			query := `INSERT INTO synced_lyrics (song_id, line, time_ms, text) VALUES ($1, $2, $3, $4)`
+			if _, err := tx.exec(query, songID, i+1, line.TimeMs, line.Text); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *LyricsRepository) DeleteSyncedLyrics(songID int) error {
+	return r.db.withTx(func(tx conn) error {
+		if err := liveSong(tx, songID); err != nil {
+			return err
+		}
+		res, err := tx.exec(`DELETE FROM synced_lyrics WHERE song_id = $1`, songID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNoSyncedLyrics
+		}
+		return nil
+	})
+}
+
+// GetSections returns the stored sections of a song in order, with the
+// version of the song they were taken from. The version is 0 when no
+// sections are stored.
+func (r *LyricsRepository) GetSections(songID int) ([]model.LyricsSection, int, error) {
+	query := `
+        SELECT position, type, number, lines, repeat_of, song_version
+        FROM song_sections WHERE song_id = $1 ORDER BY position
+    `
+	rows, err := r.db.query(query, songID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		sections []model.LyricsSection
+		version  int
+	)
+	for rows.Next() {
+		var (
+			section  model.LyricsSection
+			lines    string
+			repeatOf sql.NullInt64
+		)
+		if err := rows.Scan(&section.Position, &section.Type, &section.Number, &lines, &repeatOf, &version); err != nil {
+			return nil, 0, fmt.Errorf("scan error: %w", err)
+		}
+		section.Lines = strings.Split(lines, "\n")
+		section.RepeatOf = int(repeatOf.Int64)
+		sections = append(sections, section)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	return sections, version, nil
+}
+
+// SetSections replaces the stored sections of a song, taken from the given
+// version of it.
+func (r *LyricsRepository) SetSections(songID, version int, sections []model.LyricsSection) error {
+	return r.db.withTx(func(tx conn) error {
+		if _, err := tx.exec(`DELETE FROM song_sections WHERE song_id = $1`, songID); err != nil {
+			return err
+		}
+		for _, section := range sections {
+			query := `
+            INSERT INTO song_sections (song_id, position, type, number, lines, repeat_of, song_version)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+        `
+			_, err := tx.exec(query,
+				songID,
+				section.Position,
+				section.Type,
+				section.Number,
+				strings.Join(section.Lines, "\n"),
+				nullInt(section.RepeatOf),
+				version,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
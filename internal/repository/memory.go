@@ -0,0 +1,583 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"music/internal/model"
+	"music/pkg/trigram"
+)
+
+// memoryFilters mirrors the filter keys accepted by MainRepository.GetAllSongs.
+// Date filters compare the stored form of the release date.
+var memoryFilters = map[string]func(model.Song) string{
+	"group":        func(s model.Song) string { return model.NormalizeArtistName(s.GroupName) },
+	"artist":       func(s model.Song) string { return strconv.Itoa(s.ArtistID) },
+	"album":        func(s model.Song) string { return strconv.Itoa(s.AlbumID) },
+	"song":         func(s model.Song) string { return s.SongTitle },
+	"release":      memoryRelease,
+	"release_from": memoryRelease,
+	"release_to":   memoryRelease,
+	"year":         memoryRelease,
+	"lyrics":       func(s model.Song) string { return s.Lyrics },
+	"link":         func(s model.Song) string { return s.YouTubeLink },
+}
+
+// memoryRelease returns the release date as it would be stored; songs
+// without one never match a date filter.
+func memoryRelease(s model.Song) string {
+	if s.ReleaseDate == "" {
+		return ""
+	}
+	return releaseISO(s)
+}
+
+// MemoryRepository is a thread-safe in-memory SongRepository for tests and
+// local demos. IDs are assigned sequentially starting from 1 and are never
+// reused, like a SERIAL column. Artists are resolved by normalized name the
+// same way MainRepository does it. Every write records a revision, like
+// the SQL implementation. It also serves as the RevisionStore and
+// LyricsStore of its songs.
+type MemoryRepository struct {
+	mu             sync.RWMutex
+	songs          map[int]model.Song
+	nextID         int
+	artists        map[string]model.Artist
+	nextArtistID   int
+	revisions      map[int][]model.SongRevision
+	nextRevisionID int
+	synced         map[int][]model.SyncedLine
+	sections       map[int]memorySections
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		songs:          make(map[int]model.Song),
+		nextID:         1,
+		artists:        make(map[string]model.Artist),
+		nextArtistID:   1,
+		revisions:      make(map[int][]model.SongRevision),
+		nextRevisionID: 1,
+		synced:         make(map[int][]model.SyncedLine),
+		sections:       make(map[int]memorySections),
+	}
+}
+
+// record stores change as the next revision of song. It must be called
+// with m.mu held for writing.
+func (m *MemoryRepository) record(song model.Song, change model.SongRevision) {
+	change.ID = m.nextRevisionID
+	change.SongID = song.ID
+	change.Song = song
+	change.Revision = len(m.revisions[song.ID]) + 1
+	change.CreatedAt = time.Now()
+	m.revisions[song.ID] = append(m.revisions[song.ID], change)
+	m.nextRevisionID++
+}
+
+// resolveArtist must be called with m.mu held for writing.
+func (m *MemoryRepository) resolveArtist(name string) (model.Artist, error) {
+	normalized := model.NormalizeArtistName(name)
+	if normalized == "" {
+		return model.Artist{}, ErrArtistName
+	}
+	artist, ok := m.artists[normalized]
+	if !ok {
+		artist = model.Artist{ID: m.nextArtistID, Name: model.CleanArtistName(name), CreatedAt: time.Now()}
+		m.artists[normalized] = artist
+		m.nextArtistID++
+	}
+	return artist, nil
+}
+
+func (m *MemoryRepository) GetAllSongs(q model.SongQuery) ([]model.Song, error) {
+	if q.Limit < 0 || q.Offset < 0 {
+		return nil, errors.New("query error: limit and offset must not be negative")
+	}
+
+	songs, err := m.matchingSongs(q, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Cursor != "" {
+		q.Offset = 0
+	}
+	if q.Offset > len(songs) {
+		q.Offset = len(songs)
+	}
+	songs = songs[q.Offset:]
+	if q.Limit < len(songs) {
+		songs = songs[:q.Limit]
+	}
+	if len(songs) == 0 {
+		return nil, nil
+	}
+	return songs, nil
+}
+
+func (m *MemoryRepository) EachSong(q model.SongQuery, fn func(model.Song) error) error {
+	songs, err := m.matchingSongs(q, false)
+	if err != nil {
+		return err
+	}
+	for _, song := range songs {
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchingSongs returns the live songs matching q in its order, after the
+// cursor of q when paged.
+func (m *MemoryRepository) matchingSongs(q model.SongQuery, paged bool) ([]model.Song, error) {
+	match, err := memoryMatcher(q)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]int, 0, len(m.songs))
+	for id := range m.songs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	keys, err := songOrder(q)
+	if err != nil {
+		return nil, err
+	}
+	var after []interface{}
+	if paged && q.Cursor != "" {
+		if after, err = decodeCursor(q.Cursor, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	var songs []model.Song
+	for _, id := range ids {
+		song := m.songs[id]
+		if song.DeletedAt != nil {
+			continue
+		}
+		score, ok := match(song)
+		if !ok {
+			continue
+		}
+		song.Similarity = score
+		if after != nil && !afterCursor(keys, after, song) {
+			continue
+		}
+		songs = append(songs, song)
+	}
+
+	sort.SliceStable(songs, func(i, j int) bool {
+		return compareSongs(keys, songs[i], songs[j]) < 0
+	})
+	return songs, nil
+}
+
+func memoryMatcher(q model.SongQuery) (func(model.Song) (*float64, bool), error) {
+	type condition struct {
+		field    func(model.Song) string
+		mode     model.MatchMode
+		value    string
+		from, to string
+		period   bool
+	}
+	var conditions []condition
+
+	for param, field := range memoryFilters {
+		value, exists := q.Filters[param]
+		if !exists || value == "" {
+			continue
+		}
+		mode, err := matchMode(param, q)
+		if err != nil {
+			return nil, err
+		}
+		if period := songFilters[param].period; period != nil {
+			from, to, err := period(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFilter, param, err)
+			}
+			conditions = append(conditions, condition{field: field, from: from, to: to, period: true})
+			continue
+		}
+		arg, err := filterValue(param, value, mode)
+		if err != nil {
+			return nil, err
+		}
+		if id, ok := arg.(int); ok {
+			value = strconv.Itoa(id)
+		} else {
+			value = arg.(string)
+		}
+		conditions = append(conditions, condition{field: field, mode: mode, value: value})
+	}
+
+	return func(song model.Song) (*float64, bool) {
+		var total float64
+		fuzzy := 0
+		for _, c := range conditions {
+			got := c.field(song)
+			if c.period {
+				if got == "" || (c.from != "" && got < c.from) || (c.to != "" && got > c.to) {
+					return nil, false
+				}
+				continue
+			}
+			if c.mode != model.MatchExact {
+				got = strings.ToLower(got)
+			}
+			switch c.mode {
+			case model.MatchExact:
+				if got != c.value {
+					return nil, false
+				}
+			case model.MatchPrefix:
+				if !strings.HasPrefix(got, c.value) {
+					return nil, false
+				}
+			case model.MatchContains:
+				if !strings.Contains(got, c.value) {
+					return nil, false
+				}
+			case model.MatchFuzzy:
+				similarity := trigram.Similarity(got, c.value)
+				if similarity < trigram.Threshold {
+					return nil, false
+				}
+				total += similarity
+				fuzzy++
+			}
+		}
+		if fuzzy == 0 {
+			return nil, true
+		}
+		score := total / float64(fuzzy)
+		return &score, true
+	}, nil
+}
+
+func (m *MemoryRepository) GetSongByID(id int) (model.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	song, ok := m.songs[id]
+	if !ok || song.DeletedAt != nil {
+		return model.Song{}, ErrSongNotFound
+	}
+	return song, nil
+}
+
+// SearchSongs matches whole words like the SQL implementations: every word
+// of the query must occur in the title, group or lyrics. Title matches
+// weigh most, lyrics least.
+func (m *MemoryRepository) SearchSongs(query string, limit, offset int) ([]model.SongSearchResult, error) {
+	if limit < 0 || offset < 0 {
+		return nil, errors.New("query error: limit and offset must not be negative")
+	}
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearch
+	}
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]model.SongSearchResult, 0)
+	for _, song := range m.songs {
+		if song.DeletedAt != nil {
+			continue
+		}
+		counts := make(map[string]float64)
+		for _, field := range []struct {
+			text   string
+			weight float64
+		}{
+			{song.SongTitle, 1.0},
+			{song.GroupName, 0.4},
+			{song.Lyrics, 0.1},
+		} {
+			for _, word := range searchTerms(field.text) {
+				if wanted[word] {
+					counts[word] += field.weight
+				}
+			}
+		}
+		if len(counts) < len(wanted) {
+			continue
+		}
+
+		var rank float64
+		for _, c := range counts {
+			rank += c
+		}
+
+		var lines []string
+		for _, line := range strings.Split(song.Lyrics, "\n") {
+			if highlighted := highlightWords(line, wanted); highlighted != line {
+				lines = append(lines, strings.TrimSpace(highlighted))
+				if len(lines) == 2 {
+					break
+				}
+			}
+		}
+
+		results = append(results, model.SongSearchResult{
+			Song:           song,
+			Rank:           rank,
+			TitleHighlight: highlightWords(song.SongTitle, wanted),
+			Snippet:        strings.Join(lines, " … "),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+	if limit < len(results) {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// highlightWords wraps the words of text found in wanted in <b></b>.
+func highlightWords(text string, wanted map[string]bool) string {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		word := string(runes[i:j])
+		if wanted[strings.ToLower(word)] {
+			b.WriteString("<b>" + word + "</b>")
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func (m *MemoryRepository) FindSong(group, title string) (model.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	normalized := model.NormalizeArtistName(group)
+	found := model.Song{}
+	for _, song := range m.songs {
+		if song.DeletedAt != nil || model.NormalizeArtistName(song.GroupName) != normalized ||
+			!strings.EqualFold(song.SongTitle, title) {
+			continue
+		}
+		if found.ID == 0 || song.ID < found.ID {
+			found = song
+		}
+	}
+	if found.ID == 0 {
+		return found, ErrSongNotFound
+	}
+	return found, nil
+}
+
+func (m *MemoryRepository) AddSong(song model.Song, change model.SongRevision) (int, error) {
+	ids, err := m.AddSongs([]model.Song{song}, change)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+func (m *MemoryRepository) AddSongs(songs []model.Song, change model.SongRevision) ([]int, error) {
+	// Validate every song before storing any, so a batch is all or nothing.
+	songs = append([]model.Song(nil), songs...)
+	for i := range songs {
+		if err := normalizeTrack(&songs[i]); err != nil {
+			return nil, fmt.Errorf("song %d: %w", i+1, err)
+		}
+		if err := normalizeRelease(&songs[i]); err != nil {
+			return nil, fmt.Errorf("song %d: %w", i+1, err)
+		}
+		if model.NormalizeArtistName(songs[i].GroupName) == "" {
+			return nil, fmt.Errorf("song %d: %w", i+1, ErrArtistName)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]int, len(songs))
+	for i, song := range songs {
+		artist, err := m.resolveArtist(song.GroupName)
+		if err != nil {
+			return nil, err
+		}
+		song.ArtistID = artist.ID
+		song.GroupName = artist.Name
+		song.ID = m.nextID
+		song.CreatedAt = time.Now()
+		song.UpdatedAt = song.CreatedAt
+		song.Version = 1
+		m.songs[song.ID] = song
+		m.record(song, change)
+		m.nextID++
+		ids[i] = song.ID
+	}
+
+	return ids, nil
+}
+
+func (m *MemoryRepository) UpdateSong(id int, song model.Song, version int, change model.SongRevision) error {
+	if err := normalizeTrack(&song); err != nil {
+		return err
+	}
+	if err := normalizeRelease(&song); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.songs[id]
+	if !ok || existing.DeletedAt != nil {
+		return ErrSongNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return ErrVersionMismatch
+	}
+	artist, err := m.resolveArtist(song.GroupName)
+	if err != nil {
+		return err
+	}
+	existing.ArtistID = artist.ID
+	existing.GroupName = artist.Name
+	existing.SongTitle = song.SongTitle
+	existing.ReleaseDate = song.ReleaseDate
+	existing.ReleasePrecision = song.ReleasePrecision
+	existing.Lyrics = song.Lyrics
+	existing.YouTubeLink = song.YouTubeLink
+	existing.AlbumID = song.AlbumID
+	existing.DiscNumber = song.DiscNumber
+	existing.TrackNumber = song.TrackNumber
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	m.songs[id] = existing
+	m.record(existing, change)
+
+	return nil
+}
+
+func (m *MemoryRepository) DeleteSong(id int, version int, change model.SongRevision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.songs[id]
+	if !ok || existing.DeletedAt != nil {
+		return ErrSongNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return ErrVersionMismatch
+	}
+	now := time.Now()
+	existing.DeletedAt = &now
+	existing.Version++
+	existing.UpdatedAt = now
+	m.songs[id] = existing
+	m.record(existing, change)
+	return nil
+}
+
+func (m *MemoryRepository) GetDeletedSongs(limit, offset int) ([]model.Song, error) {
+	if limit < 0 || offset < 0 {
+		return nil, errors.New("query error: limit and offset must not be negative")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	songs := make([]model.Song, 0)
+	for _, song := range m.songs {
+		if song.DeletedAt != nil {
+			songs = append(songs, song)
+		}
+	}
+	sort.Slice(songs, func(i, j int) bool {
+		if !songs[i].DeletedAt.Equal(*songs[j].DeletedAt) {
+			return songs[i].DeletedAt.After(*songs[j].DeletedAt)
+		}
+		return songs[i].ID > songs[j].ID
+	})
+
+	if offset > len(songs) {
+		offset = len(songs)
+	}
+	songs = songs[offset:]
+	if limit < len(songs) {
+		songs = songs[:limit]
+	}
+	return songs, nil
+}
+
+// RestoreSong does not check album track positions; the memory repository
+// keeps no albums.
+func (m *MemoryRepository) RestoreSong(id int, change model.SongRevision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	song, ok := m.songs[id]
+	if !ok || song.DeletedAt == nil {
+		return ErrSongNotInTrash
+	}
+	song.DeletedAt = nil
+	song.Version++
+	song.UpdatedAt = time.Now()
+	m.songs[id] = song
+	m.record(song, change)
+	return nil
+}
+
+func (m *MemoryRepository) PurgeSongs(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for id, song := range m.songs {
+		if song.DeletedAt != nil && song.DeletedAt.Before(before) {
+			delete(m.songs, id)
+			delete(m.revisions, id)
+			delete(m.synced, id)
+			delete(m.sections, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
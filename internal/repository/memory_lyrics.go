@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"errors"
+
+	"music/internal/model"
+)
+
+// memorySections are the stored sections of a song and the song version
+// they were taken from.
+type memorySections struct {
+	version  int
+	sections []model.LyricsSection
+}
+
+func (m *MemoryRepository) GetRevisions(songID, limit, offset int) ([]model.SongRevision, error) {
+	if limit < 0 || offset < 0 {
+		return nil, errors.New("query error: limit and offset must not be negative")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.revisions[songID]
+	revisions := make([]model.SongRevision, 0)
+	for i := len(stored) - 1 - offset; i >= 0 && len(revisions) < limit; i-- {
+		revisions = append(revisions, stored[i])
+	}
+	return revisions, nil
+}
+
+func (m *MemoryRepository) GetRevision(songID, revision int) (model.SongRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.revisions[songID]
+	if revision < 1 || revision > len(stored) {
+		return model.SongRevision{}, ErrRevisionNotFound
+	}
+	return stored[revision-1], nil
+}
+
+// liveSong must be called with m.mu held.
+func (m *MemoryRepository) liveSong(id int) error {
+	if song, ok := m.songs[id]; !ok || song.DeletedAt != nil {
+		return ErrSongNotFound
+	}
+	return nil
+}
+
+func (m *MemoryRepository) GetSyncedLyrics(songID int) ([]model.SyncedLine, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := m.liveSong(songID); err != nil {
+		return nil, err
+	}
+	lines := m.synced[songID]
+	if len(lines) == 0 {
+		return nil, ErrNoSyncedLyrics
+	}
+	return append([]model.SyncedLine(nil), lines...), nil
+}
+
+func (m *MemoryRepository) SetSyncedLyrics(songID int, lines []model.SyncedLine) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.liveSong(songID); err != nil {
+		return err
+	}
+	m.synced[songID] = append([]model.SyncedLine(nil), lines...)
+	return nil
+}
+
+func (m *MemoryRepository) DeleteSyncedLyrics(songID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.liveSong(songID); err != nil {
+		return err
+	}
+	if len(m.synced[songID]) == 0 {
+		return ErrNoSyncedLyrics
+	}
+	delete(m.synced, songID)
+	return nil
+}
+
+func (m *MemoryRepository) GetSections(songID int) ([]model.LyricsSection, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.sections[songID]
+	return append([]model.LyricsSection(nil), stored.sections...), stored.version, nil
+}
+
+func (m *MemoryRepository) SetSections(songID, version int, sections []model.LyricsSection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sections[songID] = memorySections{
+		version:  version,
+		sections: append([]model.LyricsSection(nil), sections...),
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"music/internal/db"
+	"music/internal/model"
+	"music/pkg/config"
+)
+
+// songRepositories returns a fresh MemoryRepository and a MainRepository on
+// a migrated SQLite database, so that every case runs against both.
+func songRepositories(t *testing.T) map[string]SongRepository {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.DB.Path = filepath.Join(t.TempDir(), "music.db")
+	database, err := db.NewSQLite(cfg)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if _, err := database.MigrateUp(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return map[string]SongRepository{
+		"memory": NewMemoryRepository(),
+		"sqlite": NewMainRepository(database.SQLite, db.DriverSQLite),
+	}
+}
+
+var testChange = model.SongRevision{Action: "create", Actor: "test"}
+
+// seedSongs adds the songs the cases below query, trashing the last one.
+// Ids 1 to 4 are live, 5 is in the trash.
+func seedSongs(t *testing.T, repo SongRepository) {
+	t.Helper()
+
+	songs := []model.Song{
+		{GroupName: "Muse", SongTitle: "Supermassive Black Hole", ReleaseDate: "16.07.2006", Lyrics: "Ooh baby, don't you know I suffer?"},
+		{GroupName: "  the  Beatles ", SongTitle: "Yesterday", ReleaseDate: "06.08.1965", Lyrics: "All my troubles seemed so far away"},
+		{GroupName: "MUSE", SongTitle: "Uprising", ReleaseDate: "2009"},
+		{GroupName: "The Beatles", SongTitle: "Let It Be", ReleaseDate: "03.1970"},
+		{GroupName: "Muse", SongTitle: "Madness", ReleaseDate: "20.08.2012"},
+	}
+	for i, song := range songs {
+		id, err := repo.AddSong(song, testChange)
+		if err != nil {
+			t.Fatalf("add song %d: %v", i+1, err)
+		}
+		if id != i+1 {
+			t.Fatalf("song %d got id %d", i+1, id)
+		}
+	}
+	if err := repo.DeleteSong(5, 0, testChange); err != nil {
+		t.Fatalf("trash song 5: %v", err)
+	}
+}
+
+func songIDs(songs []model.Song) []int {
+	ids := make([]int, 0, len(songs))
+	for _, song := range songs {
+		ids = append(ids, song.ID)
+	}
+	return ids
+}
+
+func TestSongRepositoryFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]string
+		match   map[string]model.MatchMode
+		want    []int
+		wantErr error
+	}{
+		{name: "no filters", want: []int{1, 2, 3, 4}},
+		{name: "group ignores case", filters: map[string]string{"group": "muse"}, want: []int{1, 3}},
+		{name: "group ignores spacing", filters: map[string]string{"group": "the   beatles"}, want: []int{2, 4}},
+		{name: "artist id", filters: map[string]string{"artist": "2"}, want: []int{2, 4}},
+		{name: "exact title", filters: map[string]string{"song": "Yesterday"}, want: []int{2}},
+		{name: "exact title is case sensitive", filters: map[string]string{"song": "yesterday"}, want: []int{}},
+		{
+			name:    "title prefix",
+			filters: map[string]string{"song": "UP"},
+			match:   map[string]model.MatchMode{"song": model.MatchPrefix},
+			want:    []int{3},
+		},
+		{
+			name:    "lyrics contain",
+			filters: map[string]string{"lyrics": "troubles"},
+			match:   map[string]model.MatchMode{"lyrics": model.MatchContains},
+			want:    []int{2},
+		},
+		{name: "release day", filters: map[string]string{"release": "16.07.2006"}, want: []int{1}},
+		{name: "year", filters: map[string]string{"year": "1965"}, want: []int{2}},
+		{name: "release from", filters: map[string]string{"release_from": "1970"}, want: []int{1, 3, 4}},
+		{name: "release range", filters: map[string]string{"release_from": "1960", "release_to": "12.1999"}, want: []int{2, 4}},
+		{name: "trashed songs never match", filters: map[string]string{"song": "Madness"}, want: []int{}},
+		{name: "artist must be an id", filters: map[string]string{"artist": "muse"}, wantErr: ErrInvalidFilter},
+		{
+			name:    "lyrics are not fuzzy",
+			filters: map[string]string{"lyrics": "baby"},
+			match:   map[string]model.MatchMode{"lyrics": model.MatchFuzzy},
+			wantErr: ErrInvalidFilter,
+		},
+		{name: "malformed date", filters: map[string]string{"year": "65"}, wantErr: ErrInvalidFilter},
+	}
+
+	for name, repo := range songRepositories(t) {
+		seedSongs(t, repo)
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				songs, err := repo.GetAllSongs(model.SongQuery{Filters: tt.filters, Match: tt.match, Limit: 10})
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("error = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := songIDs(songs); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ids = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSongRepositoryPaging(t *testing.T) {
+	tests := []struct {
+		name          string
+		sort          []model.SortField
+		limit, offset int
+		want          []int
+	}{
+		{name: "first page", limit: 2, want: []int{1, 2}},
+		{name: "last page is short", limit: 3, offset: 2, want: []int{3, 4}},
+		{name: "offset past the end", limit: 10, offset: 10, want: []int{}},
+		{name: "zero limit", limit: 0, want: []int{}},
+		{name: "sorted by title", sort: []model.SortField{{Key: "song"}}, limit: 2, offset: 1, want: []int{1, 3}},
+		{name: "sorted by title descending", sort: []model.SortField{{Key: "song", Desc: true}}, limit: 2, want: []int{2, 3}},
+	}
+
+	for name, repo := range songRepositories(t) {
+		seedSongs(t, repo)
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				songs, err := repo.GetAllSongs(model.SongQuery{Sort: tt.sort, Limit: tt.limit, Offset: tt.offset})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := songIDs(songs); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ids = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSongRepositoryNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(SongRepository) error
+		want error
+	}{
+		{
+			name: "get unknown id",
+			call: func(r SongRepository) error { _, err := r.GetSongByID(42); return err },
+			want: ErrSongNotFound,
+		},
+		{
+			name: "get trashed song",
+			call: func(r SongRepository) error { _, err := r.GetSongByID(5); return err },
+			want: ErrSongNotFound,
+		},
+		{
+			name: "find trashed song",
+			call: func(r SongRepository) error { _, err := r.FindSong("muse", "madness"); return err },
+			want: ErrSongNotFound,
+		},
+		{
+			name: "update unknown id",
+			call: func(r SongRepository) error {
+				return r.UpdateSong(42, model.Song{GroupName: "Muse", SongTitle: "Hysteria"}, 0, testChange)
+			},
+			want: ErrSongNotFound,
+		},
+		{
+			name: "update trashed song",
+			call: func(r SongRepository) error {
+				return r.UpdateSong(5, model.Song{GroupName: "Muse", SongTitle: "Madness"}, 0, testChange)
+			},
+			want: ErrSongNotFound,
+		},
+		{
+			name: "update stale version",
+			call: func(r SongRepository) error {
+				return r.UpdateSong(1, model.Song{GroupName: "Muse", SongTitle: "Hysteria"}, 7, testChange)
+			},
+			want: ErrVersionMismatch,
+		},
+		{name: "delete unknown id", call: func(r SongRepository) error { return r.DeleteSong(42, 0, testChange) }, want: ErrSongNotFound},
+		{name: "delete twice", call: func(r SongRepository) error { return r.DeleteSong(5, 0, testChange) }, want: ErrSongNotFound},
+		{name: "restore live song", call: func(r SongRepository) error { return r.RestoreSong(1, testChange) }, want: ErrSongNotInTrash},
+		{name: "restore unknown id", call: func(r SongRepository) error { return r.RestoreSong(42, testChange) }, want: ErrSongNotInTrash},
+	}
+
+	for name, repo := range songRepositories(t) {
+		seedSongs(t, repo)
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				if err := tt.call(repo); !errors.Is(err, tt.want) {
+					t.Errorf("error = %v, want %v", err, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSongRepositoryIDs(t *testing.T) {
+	for name, repo := range songRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			seedSongs(t, repo)
+
+			song, err := repo.GetSongByID(2)
+			if err != nil {
+				t.Fatalf("get song 2: %v", err)
+			}
+			if song.GroupName != "the Beatles" || song.ArtistID != 2 || song.Version != 1 {
+				t.Errorf("song 2 = %q, artist %d, version %d; want %q, artist 2, version 1",
+					song.GroupName, song.ArtistID, song.Version, "the Beatles")
+			}
+
+			ids, err := repo.AddSongs([]model.Song{
+				{GroupName: "Muse", SongTitle: "Hysteria"},
+				{GroupName: "Muse", SongTitle: "Starlight"},
+			}, testChange)
+			if err != nil {
+				t.Fatalf("add songs: %v", err)
+			}
+			if want := []int{6, 7}; !reflect.DeepEqual(ids, want) {
+				t.Errorf("batch ids = %v, want %v", ids, want)
+			}
+
+			// Purged ids are not handed out again.
+			if err := repo.DeleteSong(7, 0, testChange); err != nil {
+				t.Fatalf("trash song 7: %v", err)
+			}
+			purged, err := repo.PurgeSongs(time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatalf("purge: %v", err)
+			}
+			if purged != 2 {
+				t.Errorf("purged %d songs, want 2", purged)
+			}
+			id, err := repo.AddSong(model.Song{GroupName: "Muse", SongTitle: "Resistance"}, testChange)
+			if err != nil {
+				t.Fatalf("add song: %v", err)
+			}
+			if id != 8 {
+				t.Errorf("id after purge = %d, want 8", id)
+			}
+		})
+	}
+}
+
+func TestSongRepositorySearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []int
+		wantErr error
+	}{
+		{name: "title word", query: "uprising", want: []int{3}},
+		{name: "group word", query: "beatles", want: []int{2, 4}},
+		{name: "lyrics word", query: "troubles", want: []int{2}},
+		{name: "words across group and title", query: "muse supermassive", want: []int{1}},
+		{name: "words across group, title and lyrics", query: "Beatles yesterday troubles", want: []int{2}},
+		{name: "every word must match", query: "muse yesterday", want: []int{}},
+		{name: "trashed songs are not found", query: "madness", want: []int{}},
+		{name: "no words", query: " -!? ", wantErr: ErrEmptySearch},
+	}
+
+	for name, repo := range songRepositories(t) {
+		seedSongs(t, repo)
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				results, err := repo.SearchSongs(tt.query, 10, 0)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("error = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got := make([]int, 0, len(results))
+				for _, result := range results {
+					got = append(got, result.ID)
+				}
+				sort.Ints(got)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ids = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSongRepositoryTrashTouchesSong(t *testing.T) {
+	for name, repo := range songRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			seedSongs(t, repo)
+
+			trash, err := repo.GetDeletedSongs(10, 0)
+			if err != nil || len(trash) != 1 {
+				t.Fatalf("trash = %v, %v; want song 5", songIDs(trash), err)
+			}
+			deleted := trash[0]
+			if deleted.Version != 2 || deleted.DeletedAt == nil || !deleted.UpdatedAt.Equal(*deleted.DeletedAt) {
+				t.Errorf("trashed song: version %d, updated %v, deleted %v; want version 2 updated when deleted",
+					deleted.Version, deleted.UpdatedAt, deleted.DeletedAt)
+			}
+
+			if err := repo.RestoreSong(5, testChange); err != nil {
+				t.Fatalf("restore: %v", err)
+			}
+			restored, err := repo.GetSongByID(5)
+			if err != nil {
+				t.Fatalf("get restored song: %v", err)
+			}
+			if restored.Version != 3 || restored.UpdatedAt.Before(deleted.UpdatedAt) {
+				t.Errorf("restored song: version %d, updated %v; want version 3 updated after %v",
+					restored.Version, restored.UpdatedAt, deleted.UpdatedAt)
+			}
+		})
+	}
+}
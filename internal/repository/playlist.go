@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"music/internal/model"
+)
+
+var (
+	ErrPlaylistNotFound = errors.New("playlist not found")
+	ErrPlaylistName     = errors.New("playlist name is required")
+	ErrEntryNotFound    = errors.New("playlist entry not found")
+)
+
+// positionStep is the gap left between the sort keys of adjacent playlist
+// entries. Moving an entry only rewrites its own key (the midpoint between
+// its new neighbours); the playlist is renumbered once a gap is used up.
+const positionStep = 1024
+
+type PlaylistRepository struct {
+	db conn
+}
+
+func NewPlaylistRepository(db *sql.DB, driver string) *PlaylistRepository {
+	return &PlaylistRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+const playlistColumns = `id, name, COALESCE(description, ''), created_at, updated_at`
+
+func scanPlaylist(row scanner) (model.Playlist, error) {
+	var playlist model.Playlist
+	err := row.Scan(
+		&playlist.ID,
+		&playlist.Name,
+		&playlist.Description,
+		&playlist.CreatedAt,
+		&playlist.UpdatedAt,
+	)
+	return playlist, err
+}
+
+func (r *PlaylistRepository) GetAllPlaylists(limit, offset int) ([]model.Playlist, error) {
+	var playlists []model.Playlist
+
+	query := `SELECT ` + playlistColumns + ` FROM playlists ORDER BY id LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		playlist, err := scanPlaylist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		playlists = append(playlists, playlist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return playlists, nil
+}
+
+// GetPlaylistByID returns the playlist with its entries in order and their
+// songs expanded. Entries of trashed songs are left out until the song is
+// restored.
+func (r *PlaylistRepository) GetPlaylistByID(id int) (model.Playlist, error) {
+	playlist, err := scanPlaylist(r.db.queryRow(`SELECT `+playlistColumns+` FROM playlists WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return playlist, ErrPlaylistNotFound
+		}
+		return playlist, err
+	}
+
+	query := `SELECT pe.id, pe.added_at, ` + songColumns + `
+        FROM playlist_entries pe
+        JOIN songs s ON s.id = pe.song_id
+        JOIN artists a ON a.id = s.artist_id
+        WHERE pe.playlist_id = $1 AND s.deleted_at IS NULL
+        ORDER BY pe.position, pe.id`
+
+	rows, err := r.db.query(query, id)
+	if err != nil {
+		return playlist, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	playlist.Entries = make([]model.PlaylistEntry, 0)
+	for rows.Next() {
+		var entry model.PlaylistEntry
+		song, err := scanSong(rows, &entry.ID, &entry.AddedAt)
+		if err != nil {
+			return playlist, fmt.Errorf("scan error: %w", err)
+		}
+		entry.Song = song
+		entry.Position = len(playlist.Entries) + 1
+		playlist.Entries = append(playlist.Entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return playlist, fmt.Errorf("rows error: %w", err)
+	}
+
+	return playlist, nil
+}
+
+func (r *PlaylistRepository) AddPlaylist(playlist model.Playlist) (int, error) {
+	if playlist.Name == "" {
+		return 0, ErrPlaylistName
+	}
+
+	now := time.Now()
+	query := `
+        INSERT INTO playlists (name, description, created_at, updated_at)
+        VALUES ($1, $2, $3, $4)
+    `
+	return r.db.insert(query, playlist.Name, playlist.Description, now, now)
+}
+
+// AddPlaylistWithSongs creates a playlist holding songIDs in order, all in
+// one transaction.
+func (r *PlaylistRepository) AddPlaylistWithSongs(playlist model.Playlist, songIDs []int) (int, error) {
+	if playlist.Name == "" {
+		return 0, ErrPlaylistName
+	}
+
+	var id int
+	err := r.db.withTx(func(tx conn) error {
+		now := time.Now()
+		query := `
+        INSERT INTO playlists (name, description, created_at, updated_at)
+        VALUES ($1, $2, $3, $4)
+    `
+		var err error
+		id, err = tx.insert(query, playlist.Name, playlist.Description, now, now)
+		if err != nil {
+			return err
+		}
+
+		for i, songID := range songIDs {
+			query := `
+            INSERT INTO playlist_entries (playlist_id, song_id, position, added_at)
+            VALUES ($1, $2, $3, $4)
+        `
+			if _, err := tx.exec(query, id, songID, int64(i+1)*positionStep, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdatePlaylist renames the playlist and replaces its description.
+func (r *PlaylistRepository) UpdatePlaylist(id int, playlist model.Playlist) error {
+	if playlist.Name == "" {
+		return ErrPlaylistName
+	}
+
+	query := `UPDATE playlists SET name = $1, description = $2, updated_at = $3 WHERE id = $4`
+	res, err := r.db.exec(query, playlist.Name, playlist.Description, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrPlaylistNotFound
+	}
+	return nil
+}
+
+func (r *PlaylistRepository) DeletePlaylist(id int) error {
+	return r.db.withTx(func(tx conn) error {
+		if _, err := tx.exec(`DELETE FROM playlist_entries WHERE playlist_id = $1`, id); err != nil {
+			return err
+		}
+		res, err := tx.exec(`DELETE FROM playlists WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrPlaylistNotFound
+		}
+		return nil
+	})
+}
+
+// touchPlaylist bumps updated_at and reports ErrPlaylistNotFound for
+// unknown playlists.
+func touchPlaylist(c conn, id int) error {
+	res, err := c.exec(`UPDATE playlists SET updated_at = $1 WHERE id = $2`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrPlaylistNotFound
+	}
+	return nil
+}
+
+// liveEntry restricts playlist_entries to the songs outside the trash.
+const liveEntry = `song_id IN (SELECT id FROM songs WHERE deleted_at IS NULL)`
+
+// slotPosition returns a sort key that places an entry at the 1-based index
+// position among the entries of the playlist other than excludeID. Entries
+// of trashed songs are not listed, so they do not count. A position of 0
+// or past the end appends.
+func slotPosition(c conn, playlistID, excludeID, position int) (int64, error) {
+	keys := func() ([]int64, error) {
+		var count int
+		if err := c.queryRow(`SELECT COUNT(*) FROM playlist_entries WHERE playlist_id = $1 AND id <> $2 AND `+liveEntry,
+			playlistID, excludeID).Scan(&count); err != nil {
+			return nil, err
+		}
+		if position <= 0 || position > count {
+			position = count + 1
+		}
+
+		// The neighbours of the slot are the entries at indexes position-1
+		// and position (1-based).
+		offset, limit := position-2, 2
+		if offset < 0 {
+			offset, limit = 0, 1
+		}
+		query := `
+            SELECT position FROM playlist_entries
+            WHERE playlist_id = $1 AND id <> $2 AND ` + liveEntry + `
+            ORDER BY position, id
+            LIMIT $3 OFFSET $4
+        `
+		rows, err := c.query(query, playlistID, excludeID, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var keys []int64
+		for rows.Next() {
+			var key int64
+			if err := rows.Scan(&key); err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		return keys, rows.Err()
+	}
+
+	neighbours, err := keys()
+	if err != nil {
+		return 0, err
+	}
+
+	var prev, next *int64
+	switch {
+	case position == 1 && len(neighbours) > 0:
+		next = &neighbours[0]
+	case len(neighbours) == 2:
+		prev, next = &neighbours[0], &neighbours[1]
+	case len(neighbours) == 1:
+		prev = &neighbours[0]
+	}
+
+	switch {
+	case prev == nil && next == nil:
+		return positionStep, nil
+	case prev == nil:
+		return *next - positionStep, nil
+	case next == nil:
+		return *prev + positionStep, nil
+	case *next-*prev >= 2:
+		return *prev + (*next-*prev)/2, nil
+	}
+
+	if err := renumberEntries(c, playlistID); err != nil {
+		return 0, err
+	}
+	neighbours, err = keys()
+	if err != nil {
+		return 0, err
+	}
+	return neighbours[0] + (neighbours[1]-neighbours[0])/2, nil
+}
+
+// renumberEntries spreads the sort keys of a playlist out to positionStep
+// apart again.
+func renumberEntries(c conn, playlistID int) error {
+	rows, err := c.query(`SELECT id FROM playlist_entries WHERE playlist_id = $1 ORDER BY position, id`, playlistID)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if _, err := c.exec(`UPDATE playlist_entries SET position = $1 WHERE id = $2`, int64(i+1)*positionStep, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddEntry inserts songID at the 1-based position (appending when position
+// is 0) and returns the id of the new entry.
+func (r *PlaylistRepository) AddEntry(playlistID, songID, position int) (int, error) {
+	var id int
+	err := r.db.withTx(func(tx conn) error {
+		if err := touchPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+
+		var count int
+		if err := tx.queryRow(`SELECT COUNT(*) FROM songs WHERE id = $1 AND deleted_at IS NULL`, songID).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrSongNotFound
+		}
+
+		key, err := slotPosition(tx, playlistID, 0, position)
+		if err != nil {
+			return err
+		}
+
+		query := `
+        INSERT INTO playlist_entries (playlist_id, song_id, position, added_at)
+        VALUES ($1, $2, $3, $4)
+    `
+		id, err = tx.insert(query, playlistID, songID, key, time.Now())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// MoveEntry moves an entry to the 1-based position, rewriting only the
+// moved entry's sort key.
+func (r *PlaylistRepository) MoveEntry(playlistID, entryID, position int) error {
+	return r.db.withTx(func(tx conn) error {
+		if err := touchPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+
+		var count int
+		if err := tx.queryRow(`SELECT COUNT(*) FROM playlist_entries WHERE id = $1 AND playlist_id = $2`,
+			entryID, playlistID).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrEntryNotFound
+		}
+
+		key, err := slotPosition(tx, playlistID, entryID, position)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.exec(`UPDATE playlist_entries SET position = $1 WHERE id = $2`, key, entryID)
+		return err
+	})
+}
+
+func (r *PlaylistRepository) RemoveEntry(playlistID, entryID int) error {
+	return r.db.withTx(func(tx conn) error {
+		if err := touchPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+		res, err := tx.exec(`DELETE FROM playlist_entries WHERE id = $1 AND playlist_id = $2`, entryID, playlistID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrEntryNotFound
+		}
+		return nil
+	})
+}
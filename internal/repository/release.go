@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"music/internal/model"
+)
+
+// isoDate is the layout release dates are stored and compared in. SQLite
+// has no DATE type and keeps them as text, which sorts chronologically.
+const isoDate = "2006-01-02"
+
+// noRelease stands in for a missing release date in the sort order.
+const noRelease = "0001-01-01"
+
+// normalizeRelease parses the release date of song and rewrites it in the
+// canonical form of its precision.
+func normalizeRelease(song *model.Song) error {
+	if song.ReleaseDate == "" {
+		song.ReleasePrecision = ""
+		return nil
+	}
+	t, precision, err := model.ParseReleaseDate(song.ReleaseDate)
+	if err != nil {
+		return err
+	}
+	song.ReleaseDate = model.FormatReleaseDate(t, precision)
+	song.ReleasePrecision = precision
+	return nil
+}
+
+// releaseArgs returns the release_date and release_precision column values
+// of a normalized song.
+func releaseArgs(song model.Song) (interface{}, interface{}) {
+	if song.ReleaseDate == "" {
+		return nil, nil
+	}
+	t, _, err := model.ParseReleaseDate(song.ReleaseDate)
+	if err != nil {
+		return nil, nil
+	}
+	return t.Format(isoDate), string(song.ReleasePrecision)
+}
+
+// releaseISO returns the stored form of the release date of song, or
+// noRelease.
+func releaseISO(song model.Song) string {
+	t, _, err := model.ParseReleaseDate(song.ReleaseDate)
+	if err != nil {
+		return noRelease
+	}
+	return t.Format(isoDate)
+}
+
+// nullDate scans a DATE column: lib/pq returns time.Time, SQLite the text.
+type nullDate struct {
+	Time  time.Time
+	Valid bool
+}
+
+func (d *nullDate) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		d.Time, d.Valid = time.Time{}, false
+		return nil
+	case time.Time:
+		d.Time, d.Valid = v, true
+		return nil
+	case []byte:
+		value = string(v)
+	}
+	s, ok := value.(string)
+	if !ok || len(s) < len(isoDate) {
+		return fmt.Errorf("cannot scan %T into a date", value)
+	}
+	t, err := time.Parse(isoDate, s[:len(isoDate)])
+	if err != nil {
+		return err
+	}
+	d.Time, d.Valid = t, true
+	return nil
+}
+
+// setRelease fills the release date of song from the scanned columns.
+func setRelease(song *model.Song, date nullDate, precision sql.NullString) {
+	if !date.Valid {
+		song.ReleaseDate, song.ReleasePrecision = "", ""
+		return
+	}
+	song.ReleasePrecision = model.DatePrecision(precision.String)
+	if song.ReleasePrecision == "" {
+		song.ReleasePrecision = model.PrecisionDay
+	}
+	song.ReleaseDate = model.FormatReleaseDate(date.Time, song.ReleasePrecision)
+}
+
+// releasePeriod is a date filter: it returns the first and last day a value
+// selects, either of which may be open ("").
+type releasePeriod func(value string) (from, to string, err error)
+
+// releaseWithin selects the whole period of a partial date, so "07.2006"
+// matches any day of July 2006.
+func releaseWithin(value string) (string, string, error) {
+	t, precision, err := model.ParseReleaseDate(value)
+	if err != nil {
+		return "", "", err
+	}
+	return t.Format(isoDate), model.ReleasePeriodEnd(t, precision).Format(isoDate), nil
+}
+
+func releaseFrom(value string) (string, string, error) {
+	t, _, err := model.ParseReleaseDate(value)
+	if err != nil {
+		return "", "", err
+	}
+	return t.Format(isoDate), "", nil
+}
+
+// releaseTo includes the whole period of a partial date, so "2010" ends on
+// 31.12.2010.
+func releaseTo(value string) (string, string, error) {
+	t, precision, err := model.ParseReleaseDate(value)
+	if err != nil {
+		return "", "", err
+	}
+	return "", model.ReleasePeriodEnd(t, precision).Format(isoDate), nil
+}
+
+func releaseYear(value string) (string, string, error) {
+	year, err := strconv.Atoi(value)
+	if err != nil || len(value) != 4 || year < 1 {
+		return "", "", fmt.Errorf("year must have four digits")
+	}
+	return releaseWithin(value)
+}
@@ -1,150 +1,592 @@
 package repository
 
 import (
-    "database/sql"
-    "fmt"
-    "time"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
-    "music/internal/model"
+	"music/internal/db"
+	"music/internal/model"
+	"music/pkg/trigram"
 )
 
+var (
+	ErrSongNotFound  = errors.New("song not found")
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrVersionMismatch means the song was changed since the version the
+	// caller expected.
+	ErrVersionMismatch = errors.New("song was modified by another request")
+	ErrSongNotInTrash  = errors.New("song is not in the trash")
+)
+
+// SongRepository is the storage contract used by services.MainService.
+type SongRepository interface {
+	GetAllSongs(query model.SongQuery) ([]model.Song, error)
+	// EachSong calls fn for every song matching the filters and order of
+	// query without paging, stopping at the first error.
+	EachSong(query model.SongQuery, fn func(model.Song) error) error
+	GetSongByID(id int) (model.Song, error)
+	SearchSongs(query string, limit, offset int) ([]model.SongSearchResult, error)
+	// FindSong returns the live song of the artist matching group whose
+	// title equals title ignoring case.
+	FindSong(group, title string) (model.Song, error)
+	// The writes below record change as a revision of the song: change
+	// names the action, the actor and the source revision, and is stored
+	// with the song as the write left it, atomically with the write.
+	AddSong(song model.Song, change model.SongRevision) (int, error)
+	// AddSongs stores songs in one transaction: either all of them are
+	// added or none. The ids come back in the order of songs.
+	AddSongs(songs []model.Song, change model.SongRevision) ([]int, error)
+	// UpdateSong and DeleteSong only apply when the song is still at
+	// version; 0 skips the check.
+	UpdateSong(id int, song model.Song, version int, change model.SongRevision) error
+	// DeleteSong moves the song to the trash, where only the trash methods
+	// see it.
+	DeleteSong(id int, version int, change model.SongRevision) error
+	GetDeletedSongs(limit, offset int) ([]model.Song, error)
+	RestoreSong(id int, change model.SongRevision) error
+	// PurgeSongs permanently removes songs trashed before the given time.
+	PurgeSongs(before time.Time) (int, error)
+}
+
+var (
+	_ SongRepository = (*MainRepository)(nil)
+	_ SongRepository = (*MemoryRepository)(nil)
+)
+
+// MainRepository is the SQL implementation of SongRepository. It works on
+// both PostgreSQL and SQLite; driver is one of the db.Driver* constants.
 type MainRepository struct {
-    db *sql.DB
+	db conn
 }
 
-func NewMainRepository(db *sql.DB) *MainRepository {
-    return &MainRepository{
-        db: db,
-    }
+func NewMainRepository(db *sql.DB, driver string) *MainRepository {
+	return &MainRepository{
+		db: conn{db: db, driver: driver},
+	}
 }
 
-func (m *MainRepository) GetAllSongs(filters map[string]string, limit, offset int) ([]model.Song, error) {
-    var songs []model.Song
-    
-    query := `
-        SELECT id, group_name, song_title, release_date, lyrics, youtube_link, created_at
-        FROM songs
-        WHERE 1=1
-    `
-    
-    args := make([]interface{}, 0)
-    paramCounter := 1
-    
-    validFilters := map[string]string{
-        "group":    "group_name",
-        "song":     "song_title",
-        "release":  "release_date",
-        "lyrics":   "lyrics",
-        "link":     "youtube_link",
-    }
-    
-    for param, column := range validFilters {
-        if value, exists := filters[param]; exists && value != "" {
-            query += fmt.Sprintf(" AND %s = $%d", column, paramCounter)
-            args = append(args, value)
-            paramCounter++
-        }
-    }
-    
-    query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramCounter, paramCounter+1)
-    args = append(args, limit, offset)
-    
-    
-    rows, err := m.db.Query(query, args...)
-    if err != nil {
-        return nil, fmt.Errorf("query error: %w", err)
-    }
-    defer rows.Close()
-    
-    
-    for rows.Next() {
-        var song model.Song
-        if err := rows.Scan(&song.ID, &song.GroupName, &song.SongTitle, 
-            &song.ReleaseDate, &song.Lyrics, &song.YouTubeLink, &song.CreatedAt); err != nil {
-            return nil, fmt.Errorf("scan error: %w", err)
-        }
-        songs = append(songs, song)
-    }
-    
-    if err := rows.Err(); err != nil {
-        return nil, fmt.Errorf("rows error: %w", err)
-    }
-    
-    return songs, nil
+// songFilter describes a filter key accepted by GetAllSongs.
+type songFilter struct {
+	column string
+	// numeric filters hold ids and only match exactly.
+	numeric bool
+	// fuzzy filters allow MatchFuzzy.
+	fuzzy bool
+	// period is set for date filters, which select a range of days and
+	// only match exactly.
+	period releasePeriod
+}
+
+var songFilters = map[string]songFilter{
+	"group":        {column: "a.normalized_name", fuzzy: true},
+	"artist":       {column: "s.artist_id", numeric: true},
+	"album":        {column: "s.album_id", numeric: true},
+	"song":         {column: "s.song_title", fuzzy: true},
+	"release":      {column: "s.release_date", period: releaseWithin},
+	"release_from": {column: "s.release_date", period: releaseFrom},
+	"release_to":   {column: "s.release_date", period: releaseTo},
+	"year":         {column: "s.release_date", period: releaseYear},
+	"lyrics":       {column: "s.lyrics"},
+	"link":         {column: "s.youtube_link"},
+}
+
+// matchMode returns the mode of a filter, checking that the filter
+// supports it.
+func matchMode(param string, q model.SongQuery) (model.MatchMode, error) {
+	mode, ok := q.Match[param]
+	if !ok || mode == "" {
+		return model.MatchExact, nil
+	}
+	filter := songFilters[param]
+	switch mode {
+	case model.MatchExact:
+	case model.MatchPrefix, model.MatchContains:
+		if filter.numeric || filter.period != nil {
+			return "", fmt.Errorf("%w: %s only matches exactly", ErrInvalidFilter, param)
+		}
+	case model.MatchFuzzy:
+		if !filter.fuzzy {
+			return "", fmt.Errorf("%w: %s does not support fuzzy matching", ErrInvalidFilter, param)
+		}
+	default:
+		return "", fmt.Errorf("%w: unknown match mode %q for %s", ErrInvalidFilter, mode, param)
+	}
+	return mode, nil
+}
+
+// filterValue prepares a filter value for comparison: ids are parsed and
+// text is folded the way its column is.
+func filterValue(param, value string, mode model.MatchMode) (interface{}, error) {
+	filter := songFilters[param]
+	if filter.numeric {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be an id", ErrInvalidFilter, param)
+		}
+		return id, nil
+	}
+	if param == "group" {
+		return model.NormalizeArtistName(value), nil
+	}
+	if mode == model.MatchExact {
+		return value, nil
+	}
+	return strings.ToLower(value), nil
+}
+
+// likeEscaper escapes LIKE wildcards; queries declare ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// songWhere builds the WHERE conditions for the filters of q, numbering
+// placeholders from $1. score is an expression for the mean similarity of
+// the fuzzy filters, or empty when none is used.
+func songWhere(q model.SongQuery, driver string) (where string, args []interface{}, score string, err error) {
+	conditions := []string{"s.deleted_at IS NULL"}
+	var scores []string
+
+	for param, filter := range songFilters {
+		value, exists := q.Filters[param]
+		if !exists || value == "" {
+			continue
+		}
+		mode, err := matchMode(param, q)
+		if err != nil {
+			return "", nil, "", err
+		}
+		if filter.period != nil {
+			from, to, err := filter.period(value)
+			if err != nil {
+				return "", nil, "", fmt.Errorf("%w: %s: %v", ErrInvalidFilter, param, err)
+			}
+			if from != "" {
+				args = append(args, from)
+				conditions = append(conditions, fmt.Sprintf("%s >= $%d", filter.column, len(args)))
+			}
+			if to != "" {
+				args = append(args, to)
+				conditions = append(conditions, fmt.Sprintf("%s <= $%d", filter.column, len(args)))
+			}
+			continue
+		}
+		arg, err := filterValue(param, value, mode)
+		if err != nil {
+			return "", nil, "", err
+		}
+
+		// Partial and fuzzy matches ignore case; normalized_name is
+		// already folded.
+		column := filter.column
+		if !filter.numeric && param != "group" && mode != model.MatchExact {
+			column = "LOWER(" + column + ")"
+		}
+		n := len(args) + 1
+
+		switch mode {
+		case model.MatchExact:
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", column, n))
+		case model.MatchPrefix:
+			conditions = append(conditions, fmt.Sprintf(`%s LIKE $%d ESCAPE '\'`, column, n))
+			arg = likeEscaper.Replace(arg.(string)) + "%"
+		case model.MatchContains:
+			conditions = append(conditions, fmt.Sprintf(`%s LIKE $%d ESCAPE '\'`, column, n))
+			arg = "%" + likeEscaper.Replace(arg.(string)) + "%"
+		case model.MatchFuzzy:
+			// The % operator can use the trigram indexes; SQLite compares
+			// against the same threshold.
+			if driver == db.DriverSQLite {
+				conditions = append(conditions, fmt.Sprintf("similarity(%s, $%d) >= %g", column, n, trigram.Threshold))
+			} else {
+				conditions = append(conditions, fmt.Sprintf("%s %% $%d", column, n))
+			}
+			scores = append(scores, fmt.Sprintf("similarity(%s, $%d)", column, n))
+		}
+		args = append(args, arg)
+	}
+
+	if len(scores) > 0 {
+		score = fmt.Sprintf("(%s) / %d", strings.Join(scores, " + "), len(scores))
+	}
+	return strings.Join(conditions, " AND "), args, score, nil
+}
+
+const songColumns = `s.id, a.name, s.artist_id, s.song_title, s.release_date, s.release_precision, s.lyrics, s.youtube_link,
+        COALESCE(s.album_id, 0), COALESCE(s.disc_number, 0), COALESCE(s.track_number, 0), s.created_at,
+        s.version, s.updated_at, s.deleted_at`
+
+const songsFrom = `FROM songs s JOIN artists a ON a.id = s.artist_id`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSong scans songColumns. Extra destinations are filled from the
+// columns selected before songColumns.
+func scanSong(row scanner, extra ...interface{}) (model.Song, error) {
+	var song model.Song
+	var release nullDate
+	var precision sql.NullString
+	dest := append(extra,
+		&song.ID,
+		&song.GroupName,
+		&song.ArtistID,
+		&song.SongTitle,
+		&release,
+		&precision,
+		&song.Lyrics,
+		&song.YouTubeLink,
+		&song.AlbumID,
+		&song.DiscNumber,
+		&song.TrackNumber,
+		&song.CreatedAt,
+		&song.Version,
+		&song.UpdatedAt,
+		&song.DeletedAt,
+	)
+	err := row.Scan(dest...)
+	setRelease(&song, release, precision)
+	return song, err
+}
+
+// nullInt stores zero values as NULL.
+func nullInt(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+func (m *MainRepository) GetAllSongs(q model.SongQuery) ([]model.Song, error) {
+	var songs []model.Song
+	err := m.eachSong(q, true, func(song model.Song) error {
+		songs = append(songs, song)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return songs, nil
+}
+
+// EachSong streams every song matching the filters and order of q to fn
+// straight from the result set; limit, offset and cursor are ignored. An
+// error from fn stops the iteration and is returned.
+func (m *MainRepository) EachSong(q model.SongQuery, fn func(model.Song) error) error {
+	return m.eachSong(q, false, fn)
+}
+
+func (m *MainRepository) eachSong(q model.SongQuery, paged bool, fn func(model.Song) error) error {
+	where, args, score, err := songWhere(q, m.db.driver)
+	if err != nil {
+		return err
+	}
+
+	keys, err := songOrder(q)
+	if err != nil {
+		return err
+	}
+	for i := range keys {
+		if keys[i].name == similarityKey.name {
+			keys[i].expr = score
+		}
+	}
+	if score == "" {
+		score = "NULL"
+	}
+
+	offset := q.Offset
+	if paged && q.Cursor != "" {
+		values, err := decodeCursor(q.Cursor, keys)
+		if err != nil {
+			return err
+		}
+		condition, keyArgs := keysetCondition(keys, values, len(args)+1)
+		where += " AND " + condition
+		args = append(args, keyArgs...)
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`SELECT %s AS score, %s %s WHERE %s ORDER BY %s`,
+		score, songColumns, songsFrom, where, orderBy(keys))
+	if paged {
+		query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+		args = append(args, q.Limit, offset)
+	}
+
+	rows, err := m.db.query(query, args...)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var similarity sql.NullFloat64
+		song, err := scanSong(rows, &similarity)
+		if err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		if similarity.Valid {
+			song.Similarity = &similarity.Float64
+		}
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	return nil
 }
 
 func (m *MainRepository) GetSongByID(id int) (model.Song, error) {
-    var song model.Song
-    query := `
-        SELECT id, group_name, song_title, release_date, lyrics, youtube_link, created_at
-        FROM songs
-        WHERE id = $1
-    `
-    row := m.db.QueryRow(query, id)
-    if err := row.Scan(
-        &song.ID,
-        &song.GroupName,
-        &song.SongTitle,
-        &song.ReleaseDate,
-        &song.Lyrics,
-        &song.YouTubeLink,
-        &song.CreatedAt,
-    ); err != nil {
-        if err == sql.ErrNoRows {
-            return song, fmt.Errorf("song not found")
-        }
-        return song, err
-    }
-    return song, nil
-}
-
-func (m *MainRepository) AddSong(song model.Song) (int, error) {
-    var id int
-    query := `
-        INSERT INTO songs (group_name, song_title, release_date, lyrics, youtube_link, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id
+	query := `SELECT ` + songColumns + ` ` + songsFrom + ` WHERE s.id = $1 AND s.deleted_at IS NULL`
+	song, err := scanSong(m.db.queryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return song, ErrSongNotFound
+		}
+		return song, err
+	}
+	return song, nil
+}
+
+// FindSong looks a song up by the normalized name of its artist and its
+// title; the oldest match wins.
+func (m *MainRepository) FindSong(group, title string) (model.Song, error) {
+	query := `SELECT ` + songColumns + ` ` + songsFrom + `
+        WHERE a.normalized_name = $1 AND LOWER(s.song_title) = LOWER($2) AND s.deleted_at IS NULL
+        ORDER BY s.id LIMIT 1`
+	song, err := scanSong(m.db.queryRow(query, model.NormalizeArtistName(group), title))
+	if err == sql.ErrNoRows {
+		return song, ErrSongNotFound
+	}
+	return song, err
+}
+
+// AddSong stores the song under the artist matching song.GroupName, creating
+// the artist when no artist with the same normalized name exists yet.
+func (m *MainRepository) AddSong(song model.Song, change model.SongRevision) (int, error) {
+	var id int
+	err := m.db.withTx(func(tx conn) error {
+		var err error
+		if id, err = addSong(tx, song); err != nil {
+			return err
+		}
+		return addRevision(tx, id, change)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *MainRepository) AddSongs(songs []model.Song, change model.SongRevision) ([]int, error) {
+	ids := make([]int, len(songs))
+	err := m.db.withTx(func(tx conn) error {
+		for i, song := range songs {
+			id, err := addSong(tx, song)
+			if err != nil {
+				return fmt.Errorf("song %d: %w", i+1, err)
+			}
+			if err := addRevision(tx, id, change); err != nil {
+				return fmt.Errorf("song %d: %w", i+1, err)
+			}
+			ids[i] = id
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func addSong(tx conn, song model.Song) (int, error) {
+	if err := normalizeTrack(&song); err != nil {
+		return 0, err
+	}
+	if err := normalizeRelease(&song); err != nil {
+		return 0, err
+	}
+	release, precision := releaseArgs(song)
+
+	if err := checkTrack(tx, song, 0); err != nil {
+		return 0, err
+	}
+	artistID, err := resolveArtist(tx, song.GroupName)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+        INSERT INTO songs (artist_id, song_title, release_date, release_precision, lyrics, youtube_link,
+            album_id, disc_number, track_number, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
     `
-    err := m.db.QueryRow(query,
-        song.GroupName,
-        song.SongTitle,
-        song.ReleaseDate,
-        song.Lyrics,
-        song.YouTubeLink,
-        time.Now(),
-    ).Scan(&id)
-    if err != nil {
-        return 0, err
-    }
-    return id, nil
-}
-
-func (m *MainRepository) UpdateSong(id int, song model.Song) error {
-    query := `
+	return tx.insert(query,
+		artistID,
+		song.SongTitle,
+		release,
+		precision,
+		song.Lyrics,
+		song.YouTubeLink,
+		nullInt(song.AlbumID),
+		nullInt(song.DiscNumber),
+		nullInt(song.TrackNumber),
+		time.Now(),
+	)
+}
+
+// UpdateSong overwrites the song and bumps its version.
+func (m *MainRepository) UpdateSong(id int, song model.Song, version int, change model.SongRevision) error {
+	if err := normalizeTrack(&song); err != nil {
+		return err
+	}
+	if err := normalizeRelease(&song); err != nil {
+		return err
+	}
+	release, precision := releaseArgs(song)
+
+	return m.db.withTx(func(tx conn) error {
+		if err := checkTrack(tx, song, id); err != nil {
+			return err
+		}
+		artistID, err := resolveArtist(tx, song.GroupName)
+		if err != nil {
+			return err
+		}
+		query := `
         UPDATE songs
-        SET group_name = $1, song_title = $2, release_date = $3, lyrics = $4, youtube_link = $5
-        WHERE id = $6
+        SET artist_id = $1, song_title = $2, release_date = $3, release_precision = $4, lyrics = $5,
+            youtube_link = $6, album_id = $7, disc_number = $8, track_number = $9,
+            version = version + 1, updated_at = $10
+        WHERE id = $11 AND deleted_at IS NULL AND ($12 = 0 OR version = $12)
     `
-    _, err := m.db.Exec(query,
-        song.GroupName,
-        song.SongTitle,
-        song.ReleaseDate,
-        song.Lyrics,
-        song.YouTubeLink,
-        time.Now(),
-        id,
-    )
-    if err != nil {
-        return err
-    }
-    return nil
-}
-
-func (m *MainRepository) DeleteSong(id int) error {
-    query := `DELETE FROM songs WHERE id = $1`
-    _, err := m.db.Exec(query, id)
-    if err != nil {
-        return err
-    }
-    return nil
-}
\ No newline at end of file
+		res, err := tx.exec(query,
+			artistID,
+			song.SongTitle,
+			release,
+			precision,
+			song.Lyrics,
+			song.YouTubeLink,
+			nullInt(song.AlbumID),
+			nullInt(song.DiscNumber),
+			nullInt(song.TrackNumber),
+			time.Now(),
+			id,
+			version,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return missedSong(tx, id)
+		}
+		return addRevision(tx, id, change)
+	})
+}
+
+func (m *MainRepository) DeleteSong(id int, version int, change model.SongRevision) error {
+	return m.db.withTx(func(tx conn) error {
+		query := `
+        UPDATE songs SET deleted_at = $1, version = version + 1, updated_at = $1
+        WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+    `
+		res, err := tx.exec(query, time.Now(), id, version)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return missedSong(tx, id)
+		}
+		return addRevision(tx, id, change)
+	})
+}
+
+// GetDeletedSongs lists the trash, most recently deleted first.
+func (m *MainRepository) GetDeletedSongs(limit, offset int) ([]model.Song, error) {
+	songs := make([]model.Song, 0)
+
+	query := `SELECT ` + songColumns + ` ` + songsFrom + `
+        WHERE s.deleted_at IS NOT NULL
+        ORDER BY s.deleted_at DESC, s.id DESC
+        LIMIT $1 OFFSET $2`
+	rows, err := m.db.query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		songs = append(songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return songs, nil
+}
+
+// RestoreSong takes a song out of the trash. It fails with ErrTrackTaken
+// when another song has taken its album track position meanwhile.
+func (m *MainRepository) RestoreSong(id int, change model.SongRevision) error {
+	return m.db.withTx(func(tx conn) error {
+		var song model.Song
+		var albumID, discNumber, trackNumber sql.NullInt64
+		query := `SELECT album_id, disc_number, track_number FROM songs WHERE id = $1 AND deleted_at IS NOT NULL`
+		err := tx.queryRow(query, id).Scan(&albumID, &discNumber, &trackNumber)
+		if err == sql.ErrNoRows {
+			return ErrSongNotInTrash
+		}
+		if err != nil {
+			return err
+		}
+		song.AlbumID = int(albumID.Int64)
+		song.DiscNumber = int(discNumber.Int64)
+		song.TrackNumber = int(trackNumber.Int64)
+		if err := checkTrack(tx, song, id); err != nil {
+			return err
+		}
+
+		query = `UPDATE songs SET deleted_at = NULL, version = version + 1, updated_at = $1 WHERE id = $2`
+		if _, err := tx.exec(query, time.Now(), id); err != nil {
+			return err
+		}
+		return addRevision(tx, id, change)
+	})
+}
+
+func (m *MainRepository) PurgeSongs(before time.Time) (int, error) {
+	res, err := m.db.exec(`DELETE FROM songs WHERE deleted_at IS NOT NULL AND deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// missedSong tells why a conditional write on a song changed no row: the
+// song is gone or trashed, or its version moved on.
+func missedSong(c conn, id int) error {
+	var exists int
+	err := c.queryRow(`SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrSongNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
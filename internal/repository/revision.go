@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"music/internal/model"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// RevisionStore reads the history of songs for services.MainService. The
+// revisions themselves are written by the SongRepository writes.
+type RevisionStore interface {
+	// GetRevisions lists the revisions of a song, newest first.
+	GetRevisions(songID, limit, offset int) ([]model.SongRevision, error)
+	GetRevision(songID, revision int) (model.SongRevision, error)
+}
+
+var (
+	_ RevisionStore = (*RevisionRepository)(nil)
+	_ RevisionStore = (*MemoryRepository)(nil)
+)
+
+// RevisionRepository reads the history of songs from song_revisions.
+type RevisionRepository struct {
+	db conn
+}
+
+func NewRevisionRepository(db *sql.DB, driver string) *RevisionRepository {
+	return &RevisionRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+const revisionColumns = `id, song_id, revision, action, snapshot, COALESCE(source_revision, 0), actor,
+        COALESCE(actor_user_id, 0), COALESCE(actor_api_key_id, 0), created_at`
+
+func scanRevision(row scanner) (model.SongRevision, error) {
+	var (
+		rev      model.SongRevision
+		snapshot []byte
+	)
+	err := row.Scan(
+		&rev.ID,
+		&rev.SongID,
+		&rev.Revision,
+		&rev.Action,
+		&snapshot,
+		&rev.SourceRevision,
+		&rev.Actor,
+		&rev.ActorUserID,
+		&rev.ActorAPIKeyID,
+		&rev.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return rev, ErrRevisionNotFound
+	}
+	if err != nil {
+		return rev, err
+	}
+	if err := json.Unmarshal(snapshot, &rev.Song); err != nil {
+		return rev, fmt.Errorf("revision %d snapshot: %w", rev.ID, err)
+	}
+	return rev, nil
+}
+
+// addRevision stores change as the next revision of song id, with the song
+// as tx sees it now. It must run in the transaction that made the change,
+// so the snapshot is exactly what was written.
+// touchSong bumps the version and modification time of a song changed by
+// another write in tx, such as an album track list, and records change as a
+// revision of it.
+func touchSong(tx conn, id int, change model.SongRevision) error {
+	if _, err := tx.exec(`UPDATE songs SET version = version + 1, updated_at = $1 WHERE id = $2`, time.Now(), id); err != nil {
+		return err
+	}
+	return addRevision(tx, id, change)
+}
+
+func addRevision(tx conn, id int, change model.SongRevision) error {
+	song, err := scanSong(tx.queryRow(`SELECT `+songColumns+` `+songsFrom+` WHERE s.id = $1`, id))
+	if err != nil {
+		return fmt.Errorf("revision snapshot: %w", err)
+	}
+	snapshot, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+
+	// The change holds the row lock of the song, so no other revision of
+	// it can be numbered meanwhile.
+	var number int
+	err = tx.queryRow(`SELECT COALESCE(MAX(revision), 0) + 1 FROM song_revisions WHERE song_id = $1`, id).
+		Scan(&number)
+	if err != nil {
+		return err
+	}
+	query := `
+        INSERT INTO song_revisions (song_id, revision, action, snapshot, source_revision,
+            actor, actor_user_id, actor_api_key_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+	_, err = tx.exec(query,
+		id,
+		number,
+		change.Action,
+		string(snapshot),
+		nullInt(change.SourceRevision),
+		change.Actor,
+		nullInt(change.ActorUserID),
+		nullInt(change.ActorAPIKeyID),
+		time.Now(),
+	)
+	return err
+}
+
+// GetRevisions lists the revisions of a song, newest first.
+func (r *RevisionRepository) GetRevisions(songID, limit, offset int) ([]model.SongRevision, error) {
+	revisions := make([]model.SongRevision, 0)
+
+	query := `SELECT ` + revisionColumns + ` FROM song_revisions
+        WHERE song_id = $1 ORDER BY revision DESC LIMIT $2 OFFSET $3`
+	rows, err := r.db.query(query, songID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *RevisionRepository) GetRevision(songID, revision int) (model.SongRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM song_revisions WHERE song_id = $1 AND revision = $2`
+	return scanRevision(r.db.queryRow(query, songID, revision))
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"music/internal/db"
+	"music/internal/model"
+)
+
+var ErrEmptySearch = errors.New("search query is empty")
+
+// searchTerms splits a free-text query into lowercase words.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Postgres ranks songs.search_vector (title A, lyrics C) together with
+// artists.search_vector (group B); both are generated columns with GIN
+// indexes. The query is matched against both vectors together, so its words
+// may be spread over title, group and lyrics. It accepts web search syntax:
+// "phrases", or, -word.
+const pgSearchQuery = `
+    SELECT ts_rank(s.search_vector || a.search_vector, q) AS rank,
+        ts_headline('simple', s.song_title, q, 'StartSel=<b>, StopSel=</b>, HighlightAll=true'),
+        ts_headline('simple', COALESCE(s.lyrics, ''), q,
+            'StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "'),
+        ` + songColumns + `
+    ` + songsFrom + `, websearch_to_tsquery('simple', $1) q
+    WHERE (s.search_vector || a.search_vector) @@ q AND s.deleted_at IS NULL
+    ORDER BY rank DESC, s.id
+    LIMIT $2 OFFSET $3`
+
+// SQLite searches the songs_fts table. bm25 is lower for better matches, so
+// it is negated into a rank; the weights follow the Postgres ones.
+const sqliteSearchQuery = `
+    SELECT -bm25(songs_fts, 10.0, 4.0, 1.0) AS rank,
+        highlight(songs_fts, 0, '<b>', '</b>'),
+        snippet(songs_fts, 2, '<b>', '</b>', ' … ', 20),
+        ` + songColumns + `
+    FROM songs_fts
+    JOIN songs s ON s.id = songs_fts.rowid
+    JOIN artists a ON a.id = s.artist_id
+    WHERE songs_fts MATCH $1 AND s.deleted_at IS NULL
+    ORDER BY rank DESC, s.id
+    LIMIT $2 OFFSET $3`
+
+// SearchSongs runs a full-text search over title, group and lyrics and
+// returns the best matches first.
+func (m *MainRepository) SearchSongs(query string, limit, offset int) ([]model.SongSearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearch
+	}
+
+	sqlQuery, arg := pgSearchQuery, query
+	if m.db.driver == db.DriverSQLite {
+		// Quoting every word keeps FTS5 query syntax out of user input;
+		// the words must all match.
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + term + `"`
+		}
+		sqlQuery, arg = sqliteSearchQuery, strings.Join(quoted, " ")
+	}
+
+	rows, err := m.db.query(sqlQuery, arg, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]model.SongSearchResult, 0)
+	for rows.Next() {
+		var result model.SongSearchResult
+		song, err := scanSong(rows, &result.Rank, &result.TitleHighlight, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		result.Song = song
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return results, nil
+}
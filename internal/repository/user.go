@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"music/internal/model"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("username is already taken")
+	ErrInvalidRole  = errors.New("role must be viewer, editor or admin")
+	ErrLastAdmin    = errors.New("cannot remove the last admin")
+)
+
+type UserRepository struct {
+	db conn
+}
+
+func NewUserRepository(db *sql.DB, driver string) *UserRepository {
+	return &UserRepository{
+		db: conn{db: db, driver: driver},
+	}
+}
+
+const userColumns = `id, username, role, password_hash, created_at`
+
+func scanUser(row scanner) (model.User, error) {
+	var user model.User
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Role,
+		&user.PasswordHash,
+		&user.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// AddUser stores a new user. Without a role the user becomes a viewer, or
+// an admin when it is the first account.
+func (r *UserRepository) AddUser(user model.User) (int, error) {
+	if user.Role != "" && !user.Role.Valid() {
+		return 0, ErrInvalidRole
+	}
+
+	var id int
+	err := r.db.withTx(func(tx conn) error {
+		var count int
+		if err := tx.queryRow(`SELECT COUNT(*) FROM users WHERE username = $1`, user.Username).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUserExists
+		}
+
+		if user.Role == "" {
+			if err := tx.queryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+				return err
+			}
+			user.Role = model.RoleViewer
+			if count == 0 {
+				user.Role = model.RoleAdmin
+			}
+		}
+
+		query := `
+        INSERT INTO users (username, role, password_hash, created_at)
+        VALUES ($1, $2, $3, $4)
+    `
+		var err error
+		id, err = tx.insert(query, user.Username, user.Role, user.PasswordHash, time.Now())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *UserRepository) GetUserByID(id int) (model.User, error) {
+	return scanUser(r.db.queryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+func (r *UserRepository) GetUserByUsername(username string) (model.User, error) {
+	return scanUser(r.db.queryRow(`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+}
+
+func (r *UserRepository) GetAllUsers(limit, offset int) ([]model.User, error) {
+	var users []model.User
+
+	rows, err := r.db.query(`SELECT `+userColumns+` FROM users ORDER BY id LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return users, nil
+}
+
+// keepAdmin refuses to take the admin role away from the last admin.
+func keepAdmin(c conn, id int) error {
+	var role model.Role
+	if err := c.queryRow(`SELECT role FROM users WHERE id = $1`, id).Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if role != model.RoleAdmin {
+		return nil
+	}
+
+	var admins int
+	if err := c.queryRow(`SELECT COUNT(*) FROM users WHERE role = $1`, model.RoleAdmin).Scan(&admins); err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func (r *UserRepository) SetUserRole(id int, role model.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	return r.db.withTx(func(tx conn) error {
+		if role != model.RoleAdmin {
+			if err := keepAdmin(tx, id); err != nil {
+				return err
+			}
+		}
+		res, err := tx.exec(`UPDATE users SET role = $1 WHERE id = $2`, role, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+func (r *UserRepository) DeleteUser(id int) error {
+	return r.db.withTx(func(tx conn) error {
+		if err := keepAdmin(tx, id); err != nil {
+			return err
+		}
+		_, err := tx.exec(`DELETE FROM users WHERE id = $1`, id)
+		return err
+	})
+}
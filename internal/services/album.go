@@ -0,0 +1,57 @@
+package services
+
+import (
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type AlbumService struct {
+	repo *repository.AlbumRepository
+	log  *logger.Logger
+}
+
+func NewAlbumService(repo *repository.AlbumRepository, log *logger.Logger) *AlbumService {
+	return &AlbumService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *AlbumService) GetAllAlbums(filters map[string]string, limit, offset int) ([]model.Album, error) {
+	s.log.Info("Getting albums", logrus.Fields{"filters": filters, "limit": limit, "offset": offset})
+	return s.repo.GetAllAlbums(filters, limit, offset)
+}
+
+func (s *AlbumService) GetAlbumByID(id int) (model.Album, error) {
+	s.log.Info("Getting album by id", logrus.Fields{"id": id})
+	return s.repo.GetAlbumByID(id)
+}
+
+func (s *AlbumService) GetAlbumTracks(id int) ([]model.Song, error) {
+	s.log.Info("Getting album tracks", logrus.Fields{"id": id})
+
+	if _, err := s.repo.GetAlbumByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAlbumTracks(id)
+}
+
+// AddAlbum, UpdateAlbum and DeleteAlbum record the songs whose track
+// position they change as updated by actor.
+func (s *AlbumService) AddAlbum(album model.Album, actor model.Principal) (int, error) {
+	s.log.Info("Adding album", logrus.Fields{"title": album.Title, "group": album.GroupName})
+	return s.repo.AddAlbum(album, songChange(model.RevisionUpdate, actor, 0))
+}
+
+func (s *AlbumService) UpdateAlbum(id int, album model.Album, actor model.Principal) error {
+	s.log.Info("Updating album", logrus.Fields{"id": id, "title": album.Title})
+	return s.repo.UpdateAlbum(id, album, songChange(model.RevisionUpdate, actor, 0))
+}
+
+func (s *AlbumService) DeleteAlbum(id int, actor model.Principal) error {
+	s.log.Info("Deleting album", logrus.Fields{"id": id})
+	return s.repo.DeleteAlbum(id, songChange(model.RevisionUpdate, actor, 0))
+}
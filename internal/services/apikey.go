@@ -0,0 +1,111 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/logger"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// apiKeyPrefix marks API keys so they can be told apart from access tokens.
+const apiKeyPrefix = "mk_"
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid or revoked api key")
+	ErrAPIKeyName    = errors.New("api key name is required")
+	ErrAPIKeyScopes  = errors.New("api key needs at least one known scope")
+)
+
+type APIKeyService struct {
+	repo *repository.APIKeyRepository
+	log  *logger.Logger
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepository, log *logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// IsAPIKey reports whether a credential looks like an API key rather than an
+// access token.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey generates a key for the scopes. The secret is only part of the
+// returned value; the repository keeps its SHA-256 hash.
+func (s *APIKeyService) CreateKey(req model.APIKeyRequest, createdBy int) (model.NewAPIKey, error) {
+	s.log.Info("Creating api key", logrus.Fields{"name": req.Name, "scopes": req.Scopes, "created_by": createdBy})
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return model.NewAPIKey{}, ErrAPIKeyName
+	}
+	if len(req.Scopes) == 0 {
+		return model.NewAPIKey{}, ErrAPIKeyScopes
+	}
+	for _, scope := range req.Scopes {
+		if !model.ValidPermission(scope) {
+			return model.NewAPIKey{}, fmt.Errorf("%w: %q", ErrAPIKeyScopes, scope)
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return model.NewAPIKey{}, fmt.Errorf("generate api key: %w", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+
+	id, err := s.repo.AddKey(model.APIKey{
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		Scopes:    req.Scopes,
+		CreatedBy: createdBy,
+	}, hashAPIKey(key))
+	if err != nil {
+		return model.NewAPIKey{}, err
+	}
+
+	stored, err := s.repo.GetKeyByID(id)
+	if err != nil {
+		return model.NewAPIKey{}, err
+	}
+	return model.NewAPIKey{APIKey: stored, Key: key}, nil
+}
+
+func (s *APIKeyService) GetAllKeys(limit, offset int) ([]model.APIKey, error) {
+	s.log.Info("Getting api keys", logrus.Fields{"limit": limit, "offset": offset})
+	return s.repo.GetAllKeys(limit, offset)
+}
+
+func (s *APIKeyService) RevokeKey(id int) error {
+	s.log.Info("Revoking api key", logrus.Fields{"id": id})
+	return s.repo.RevokeKey(id)
+}
+
+// Authenticate resolves an API key to a principal limited to its scopes.
+func (s *APIKeyService) Authenticate(key string) (model.Principal, error) {
+	stored, err := s.repo.FindActiveKey(hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return model.Principal{}, ErrInvalidAPIKey
+		}
+		return model.Principal{}, err
+	}
+
+	return model.Principal{APIKeyID: stored.ID, APIKeyName: stored.Name, Scopes: stored.Scopes}, nil
+}
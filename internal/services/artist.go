@@ -0,0 +1,62 @@
+package services
+
+import (
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/logger"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ArtistService struct {
+	repo  *repository.ArtistRepository
+	songs repository.SongRepository
+	log   *logger.Logger
+}
+
+func NewArtistService(repo *repository.ArtistRepository, songs repository.SongRepository, log *logger.Logger) *ArtistService {
+	return &ArtistService{
+		repo:  repo,
+		songs: songs,
+		log:   log,
+	}
+}
+
+func (s *ArtistService) GetAllArtists(name string, limit, offset int) ([]model.Artist, error) {
+	s.log.Info("Getting artists", logrus.Fields{"name": name, "limit": limit, "offset": offset})
+	return s.repo.GetAllArtists(name, limit, offset)
+}
+
+func (s *ArtistService) GetArtistByID(id int) (model.Artist, error) {
+	s.log.Info("Getting artist by id", logrus.Fields{"id": id})
+	return s.repo.GetArtistByID(id)
+}
+
+func (s *ArtistService) AddArtist(artist model.Artist) (int, error) {
+	s.log.Info("Adding artist", logrus.Fields{"name": artist.Name})
+	return s.repo.AddArtist(artist)
+}
+
+func (s *ArtistService) UpdateArtist(id int, artist model.Artist) error {
+	s.log.Info("Updating artist", logrus.Fields{"id": id, "name": artist.Name})
+	return s.repo.UpdateArtist(id, artist)
+}
+
+func (s *ArtistService) DeleteArtist(id int) error {
+	s.log.Info("Deleting artist", logrus.Fields{"id": id})
+	return s.repo.DeleteArtist(id)
+}
+
+func (s *ArtistService) GetArtistSongs(id, limit, offset int) ([]model.Song, error) {
+	s.log.Info("Getting artist songs", logrus.Fields{"id": id, "limit": limit, "offset": offset})
+
+	if _, err := s.repo.GetArtistByID(id); err != nil {
+		return nil, err
+	}
+	return s.songs.GetAllSongs(model.SongQuery{
+		Filters: map[string]string{"artist": strconv.Itoa(id)},
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/config"
+	"music/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const tokenIssuer = "music-api"
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidUsername    = errors.New("username must be 3 to 64 characters without spaces")
+	ErrInvalidPassword    = errors.New("password must be at least 8 characters and at most 72 bytes")
+)
+
+type AuthService struct {
+	users  *repository.UserRepository
+	secret []byte
+	ttl    time.Duration
+	log    *logger.Logger
+}
+
+func NewAuthService(users *repository.UserRepository, cfg *config.Config, log *logger.Logger) (*AuthService, error) {
+	secret := []byte(cfg.Auth.JWTSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate JWT secret: %w", err)
+		}
+		log.Warn("JWT_SECRET is not set, using a random key; tokens will not survive a restart", logrus.Fields{})
+	}
+
+	return &AuthService{
+		users:  users,
+		secret: secret,
+		ttl:    cfg.Auth.TokenTTL,
+		log:    log,
+	}, nil
+}
+
+type tokenClaims struct {
+	Username string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+func (s *AuthService) Register(creds model.Credentials) (model.User, error) {
+	s.log.Info("Registering user", logrus.Fields{"username": creds.Username})
+
+	username := strings.TrimSpace(creds.Username)
+	if n := utf8.RuneCountInString(username); n < 3 || n > 64 || strings.ContainsAny(username, " \t\n") {
+		return model.User{}, ErrInvalidUsername
+	}
+	if utf8.RuneCountInString(creds.Password) < 8 || len(creds.Password) > 72 {
+		return model.User{}, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user := model.User{Username: username, PasswordHash: string(hash)}
+	id, err := s.users.AddUser(user)
+	if err != nil {
+		return model.User{}, err
+	}
+	return s.users.GetUserByID(id)
+}
+
+// Login checks the credentials and issues a signed access token.
+func (s *AuthService) Login(creds model.Credentials) (model.Token, error) {
+	s.log.Info("User login", logrus.Fields{"username": creds.Username})
+
+	user, err := s.users.GetUserByUsername(strings.TrimSpace(creds.Username))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return model.Token{}, ErrInvalidCredentials
+		}
+		return model.Token{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(creds.Password)); err != nil {
+		return model.Token{}, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	claims := tokenClaims{
+		Username: user.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return model.Token{}, err
+	}
+
+	return model.Token{
+		AccessToken: signed,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.ttl.Seconds()),
+	}, nil
+}
+
+// Authenticate validates an access token and returns its principal.
+func (s *AuthService) Authenticate(token string) (model.Principal, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return model.Principal{}, ErrInvalidToken
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return model.Principal{}, ErrInvalidToken
+	}
+
+	// The role is read on every request so that role changes and deleted
+	// accounts take effect before the token expires.
+	user, err := s.users.GetUserByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return model.Principal{}, ErrInvalidToken
+		}
+		return model.Principal{}, err
+	}
+
+	return model.Principal{UserID: user.ID, Username: user.Username, Role: user.Role}, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"music/internal/model"
+)
+
+var ErrInvalidCSV = errors.New("invalid CSV")
+
+// songCSVField maps a CSV column, named after the JSON field of
+// model.Song, to the song. Read-only columns have no set; an import
+// accepts and ignores them so that an export can be imported again.
+type songCSVField struct {
+	get func(model.Song) string
+	set func(*model.Song, string) error
+}
+
+func csvAtoi(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid number", value)
+	}
+	return n, nil
+}
+
+func csvItoa(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// songCSVColumns lists the song fields that can be imported from CSV.
+var songCSVColumns = []string{
+	"group_name", "song_title", "release_date", "lyrics", "youtube_link",
+	"album_id", "disc_number", "track_number",
+}
+
+// songCSVExport lists the columns of a CSV export in order.
+var songCSVExport = []string{
+	"id", "group_name", "artist_id", "song_title", "release_date", "release_precision",
+	"lyrics", "youtube_link", "album_id", "disc_number", "track_number",
+	"version", "created_at", "updated_at",
+}
+
+var songCSVFields = map[string]songCSVField{
+	"id":                {get: func(s model.Song) string { return strconv.Itoa(s.ID) }},
+	"artist_id":         {get: func(s model.Song) string { return strconv.Itoa(s.ArtistID) }},
+	"release_precision": {get: func(s model.Song) string { return string(s.ReleasePrecision) }},
+	"version":           {get: func(s model.Song) string { return strconv.Itoa(s.Version) }},
+	"created_at":        {get: func(s model.Song) string { return s.CreatedAt.UTC().Format(time.RFC3339) }},
+	"updated_at":        {get: func(s model.Song) string { return s.UpdatedAt.UTC().Format(time.RFC3339) }},
+	"group_name": {
+		get: func(s model.Song) string { return s.GroupName },
+		set: func(s *model.Song, v string) error { s.GroupName = v; return nil },
+	},
+	"song_title": {
+		get: func(s model.Song) string { return s.SongTitle },
+		set: func(s *model.Song, v string) error { s.SongTitle = v; return nil },
+	},
+	"release_date": {
+		get: func(s model.Song) string { return s.ReleaseDate },
+		set: func(s *model.Song, v string) error {
+			if v != "" {
+				if _, _, err := model.ParseReleaseDate(v); err != nil {
+					return err
+				}
+			}
+			s.ReleaseDate = v
+			return nil
+		},
+	},
+	"lyrics": {
+		get: func(s model.Song) string { return s.Lyrics },
+		set: func(s *model.Song, v string) error { s.Lyrics = v; return nil },
+	},
+	"youtube_link": {
+		get: func(s model.Song) string { return s.YouTubeLink },
+		set: func(s *model.Song, v string) error { s.YouTubeLink = v; return nil },
+	},
+	"album_id": {
+		get: func(s model.Song) string { return csvItoa(s.AlbumID) },
+		set: func(s *model.Song, v string) (err error) { s.AlbumID, err = csvAtoi(v); return err },
+	},
+	"disc_number": {
+		get: func(s model.Song) string { return csvItoa(s.DiscNumber) },
+		set: func(s *model.Song, v string) (err error) { s.DiscNumber, err = csvAtoi(v); return err },
+	},
+	"track_number": {
+		get: func(s model.Song) string { return csvItoa(s.TrackNumber) },
+		set: func(s *model.Song, v string) (err error) { s.TrackNumber, err = csvAtoi(v); return err },
+	},
+}
+
+// csvHeader resolves the header row of an import into song fields. Column
+// names ignore case and surrounding spaces; group_name and song_title are
+// required, read-only columns are skipped and unknown columns are rejected.
+func csvHeader(header []string) ([]songCSVField, error) {
+	fields := make([]songCSVField, len(header))
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		field, ok := songCSVFields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q, expected some of %s", ErrInvalidCSV, name, strings.Join(songCSVColumns, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidCSV, name)
+		}
+		seen[name] = true
+		fields[i] = field
+	}
+	if !seen["group_name"] || !seen["song_title"] {
+		return nil, fmt.Errorf("%w: group_name and song_title columns are required", ErrInvalidCSV)
+	}
+	return fields, nil
+}
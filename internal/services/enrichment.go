@@ -0,0 +1,198 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"music/internal/model"
+	"music/pkg/breaker"
+	"music/pkg/config"
+	"music/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrEnrichment means the external API answered with an error or a body
+	// that could not be read.
+	ErrEnrichment = errors.New("external API error")
+	// ErrEnrichmentTimeout means the external API did not answer in time,
+	// retries included.
+	ErrEnrichmentTimeout = errors.New("external API timed out")
+	// ErrEnrichmentUnavailable means calls to the external API are paused
+	// after repeated failures.
+	ErrEnrichmentUnavailable = errors.New("external API is unavailable, try again later")
+)
+
+// EnrichmentClient asks the external API for song details. Each request is
+// bounded by a timeout; timeouts, network errors and 5xx answers are
+// retried with exponential backoff, and a circuit breaker refuses calls
+// for a while once the API keeps failing.
+type EnrichmentClient struct {
+	client  *http.Client
+	breaker *breaker.Breaker
+	cfg     *config.Config
+	log     *logger.Logger
+
+	calls    atomic.Uint64
+	attempts atomic.Uint64
+	retried  atomic.Uint64
+}
+
+func NewEnrichmentClient(cfg *config.Config, log *logger.Logger) *EnrichmentClient {
+	c := &EnrichmentClient{
+		client:  &http.Client{Timeout: cfg.Enrichment.Timeout},
+		breaker: breaker.New(cfg.Enrichment.BreakerFailures, cfg.Enrichment.BreakerCooldown),
+		cfg:     cfg,
+		log:     log,
+	}
+	c.breaker.OnStateChange(func(from, to breaker.State) {
+		fields := logrus.Fields{"from": from, "to": to}
+		if to == breaker.Open {
+			c.log.Warn("External API circuit opened", fields)
+			return
+		}
+		c.log.Info("External API circuit changed state", fields)
+	})
+	return c
+}
+
+// retryable marks a failed attempt that may succeed when repeated and that
+// counts against the breaker.
+type retryable struct{ err error }
+
+func (e retryable) Error() string { return e.err.Error() }
+func (e retryable) Unwrap() error { return e.err }
+
+// SongDetail asks the external API for the lyrics, release date and link
+// of a song.
+func (c *EnrichmentClient) SongDetail(group, song string) (model.SongDetail, error) {
+	c.calls.Add(1)
+	target := fmt.Sprintf("%s/info?group=%s&song=%s", c.cfg.ExternalAPI, url.QueryEscape(group), url.QueryEscape(song))
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if err := c.breaker.Allow(); err != nil {
+			return model.SongDetail{}, ErrEnrichmentUnavailable
+		}
+
+		c.attempts.Add(1)
+		detail, err := c.get(target)
+
+		var transient retryable
+		if !errors.As(err, &transient) {
+			// The API answered, even if only to say it does not know
+			// the song.
+			c.breaker.Success()
+			return detail, err
+		}
+		c.breaker.Failure(transient.err)
+		lastErr = transient.err
+
+		if attempt >= c.cfg.Enrichment.Retries {
+			break
+		}
+		if c.breaker.State() == breaker.Open {
+			// This failure tripped the breaker; waiting would not help.
+			return model.SongDetail{}, ErrEnrichmentUnavailable
+		}
+		delay := c.delay(attempt)
+		c.log.Warn("Retrying external API request", logrus.Fields{
+			"group": group, "song": song, "attempt": attempt + 1, "delay": delay.String(), "error": lastErr,
+		})
+		c.retried.Add(1)
+		time.Sleep(delay)
+	}
+	return model.SongDetail{}, lastErr
+}
+
+// get makes one request. Failures worth another attempt come back as
+// retryable.
+func (c *EnrichmentClient) get(target string) (model.SongDetail, error) {
+	var detail model.SongDetail
+
+	resp, err := c.client.Get(target)
+	if err != nil {
+		return detail, retryable{transportError(err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return detail, retryable{transportError(err)}
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return detail, retryable{fmt.Errorf("%w: status %d", ErrEnrichment, resp.StatusCode)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return detail, fmt.Errorf("%w: status %d", ErrEnrichment, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, &detail); err != nil {
+		return detail, fmt.Errorf("%w: %v", ErrEnrichment, err)
+	}
+	return detail, nil
+}
+
+// transportError tells timeouts apart from other failures to reach the API.
+func transportError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %v", ErrEnrichmentTimeout, err)
+	}
+	return fmt.Errorf("%w: %v", ErrEnrichment, err)
+}
+
+// delay is the wait before retry attempt+1: the backoff doubled for each
+// earlier retry, capped at MaxBackoff, of which a random half is taken off
+// so that clients failing together do not retry together.
+func (c *EnrichmentClient) delay(attempt int) time.Duration {
+	d, limit := c.cfg.Enrichment.Backoff, c.cfg.Enrichment.MaxBackoff
+	for i := 0; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	if limit > 0 && d > limit {
+		d = limit
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// Status reports the breaker state and request counters.
+func (c *EnrichmentClient) Status() model.EnrichmentStatus {
+	snapshot := c.breaker.Snapshot()
+	status := model.EnrichmentStatus{
+		State:               string(snapshot.State),
+		ConsecutiveFailures: snapshot.ConsecutiveFailures,
+		Calls:               c.calls.Load(),
+		Attempts:            c.attempts.Load(),
+		Retries:             c.retried.Load(),
+		Successes:           snapshot.Successes,
+		Failures:            snapshot.Failures,
+		Rejected:            snapshot.Rejected,
+		LastError:           snapshot.LastError,
+		Timeout:             c.cfg.Enrichment.Timeout.String(),
+		MaxRetries:          c.cfg.Enrichment.Retries,
+		FailureThreshold:    c.cfg.Enrichment.BreakerFailures,
+		Cooldown:            c.cfg.Enrichment.BreakerCooldown.String(),
+	}
+	if !snapshot.OpenedAt.IsZero() {
+		status.OpenedAt = &snapshot.OpenedAt
+		status.RetryAt = &snapshot.RetryAt
+	}
+	if !snapshot.LastFailureAt.IsZero() {
+		status.LastFailureAt = &snapshot.LastFailureAt
+	}
+	return status
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"music/internal/model"
+	"music/pkg/playlistfile"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrExportFormat = errors.New("format must be csv, json, ndjson, m3u, m3u8 or xspf")
+
+// ExportFormat is the encoding of a library export.
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportJSON   ExportFormat = "json"
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportM3U    ExportFormat = ExportFormat(playlistfile.M3U)
+	ExportM3U8   ExportFormat = ExportFormat(playlistfile.M3U8)
+	ExportXSPF   ExportFormat = ExportFormat(playlistfile.XSPF)
+)
+
+// ContentType is the media type of an export in format.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	case ExportM3U, ExportM3U8, ExportXSPF:
+		return playlistfile.Format(f).ContentType()
+	}
+	return "application/json"
+}
+
+// ExportSongs writes every song matching the filters and order of q to w,
+// one row at a time as it is read from the database. Limit, offset and
+// cursor of q are ignored. CSV exports use the import columns plus the
+// read-only ones, so they can be imported again. Playlist formats list the
+// songs with their YouTube links.
+func (s *MainService) ExportSongs(w io.Writer, q model.SongQuery, format ExportFormat) (int, error) {
+	s.log.Info("Exporting songs", logrus.Fields{"filters": q.Filters, "match": q.Match, "format": format})
+
+	var (
+		write  func(model.Song) error
+		finish func() error
+		count  int
+	)
+	switch format {
+	case ExportCSV:
+		out := csv.NewWriter(w)
+		record := make([]string, len(songCSVExport))
+		write = func(song model.Song) error {
+			if count == 0 {
+				if err := out.Write(songCSVExport); err != nil {
+					return err
+				}
+			}
+			for i, column := range songCSVExport {
+				record[i] = songCSVFields[column].get(song)
+			}
+			return out.Write(record)
+		}
+		finish = func() error {
+			if count == 0 {
+				out.Write(songCSVExport)
+			}
+			out.Flush()
+			return out.Error()
+		}
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		write = func(song model.Song) error {
+			separator := ","
+			if count == 0 {
+				separator = "["
+			}
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+			return enc.Encode(song)
+		}
+		finish = func() error {
+			end := "]\n"
+			if count == 0 {
+				end = "[]\n"
+			}
+			_, err := io.WriteString(w, end)
+			return err
+		}
+	case ExportNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(song model.Song) error { return enc.Encode(song) }
+		finish = func() error { return nil }
+	case ExportM3U, ExportM3U8, ExportXSPF:
+		// The header is written with the first song, so that a bad query
+		// fails before anything is sent.
+		var out *playlistfile.Writer
+		open := func() (err error) {
+			if out == nil {
+				out, err = playlistfile.NewWriter(w, playlistfile.Format(format), "")
+			}
+			return err
+		}
+		write = func(song model.Song) error {
+			if err := open(); err != nil {
+				return err
+			}
+			return out.Write(songTrack(song))
+		}
+		finish = func() error {
+			if err := open(); err != nil {
+				return err
+			}
+			return out.Close()
+		}
+	default:
+		return 0, ErrExportFormat
+	}
+
+	err := s.repo.EachSong(q, func(song model.Song) error {
+		song.Similarity = nil
+		if err := write(song); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := finish(); err != nil {
+		return count, err
+	}
+
+	s.log.Info("Exported songs", logrus.Fields{"count": count, "format": format})
+	return count, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"music/internal/model"
+	"music/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// importBatchSize is the number of songs inserted per transaction.
+const importBatchSize = 100
+
+type pendingSong struct {
+	row  int
+	song model.Song
+}
+
+// songKey identifies a song the way FindSong matches it.
+func songKey(group, title string) string {
+	return model.NormalizeArtistName(group) + "\x00" + strings.ToLower(title)
+}
+
+// ImportSongs adds the songs of a CSV document whose header names
+// model.Song fields. Rows that are invalid, or already in the library or
+// earlier in the file, are reported and skipped without failing the rest.
+// With enrich, rows without lyrics are completed from the external API.
+func (s *MainService) ImportSongs(r io.Reader, enrich bool, actor model.Principal) (model.ImportReport, error) {
+	s.log.Info("Importing songs", logrus.Fields{"enrich": enrich})
+
+	report := model.ImportReport{Rows: make([]model.ImportRow, 0)}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return report, fmt.Errorf("%w: the document is empty", ErrInvalidCSV)
+	}
+	if err != nil {
+		return report, fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+	}
+	fields, err := csvHeader(header)
+	if err != nil {
+		return report, err
+	}
+
+	seen := make(map[string]int)
+	// duplicates maps rows repeating an earlier row of the file to it.
+	duplicates := make(map[int]int)
+	var batch []pendingSong
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		report.Rows = append(report.Rows, model.ImportRow{Row: len(report.Rows) + 1})
+		row := &report.Rows[len(report.Rows)-1]
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			row.Status, row.Error = model.ImportFailed, parseErr.Err.Error()
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+		}
+
+		song, err := csvSong(fields, header, record)
+		row.GroupName, row.SongTitle = song.GroupName, song.SongTitle
+		if err != nil {
+			row.Status, row.Error = model.ImportFailed, err.Error()
+			continue
+		}
+
+		key := songKey(song.GroupName, song.SongTitle)
+		if first, ok := seen[key]; ok {
+			row.Status = model.ImportDuplicate
+			duplicates[row.Row] = first
+			continue
+		}
+		existing, err := s.repo.FindSong(song.GroupName, song.SongTitle)
+		if err == nil {
+			row.Status, row.DuplicateOf = model.ImportDuplicate, existing.ID
+			continue
+		}
+		if !errors.Is(err, repository.ErrSongNotFound) {
+			row.Status, row.Error = model.ImportFailed, err.Error()
+			continue
+		}
+		seen[key] = row.Row
+
+		if enrich && song.Lyrics == "" {
+			if err := s.enrichSong(&song); err != nil {
+				row.Warning = "enrichment failed: " + err.Error()
+			}
+		}
+
+		batch = append(batch, pendingSong{row: row.Row, song: song})
+		if len(batch) == importBatchSize {
+			s.addBatch(batch, &report, actor)
+			batch = batch[:0]
+		}
+	}
+	s.addBatch(batch, &report, actor)
+
+	for row, first := range duplicates {
+		report.Rows[row-1].DuplicateOf = report.Rows[first-1].ID
+	}
+	for _, row := range report.Rows {
+		switch row.Status {
+		case model.ImportCreated:
+			report.Created++
+		case model.ImportDuplicate:
+			report.Duplicates++
+		case model.ImportFailed:
+			report.Failed++
+		}
+	}
+
+	s.log.Info("Imported songs", logrus.Fields{
+		"created":    report.Created,
+		"duplicates": report.Duplicates,
+		"failed":     report.Failed,
+	})
+	return report, nil
+}
+
+// csvSong builds a song from one record.
+func csvSong(fields []songCSVField, header, record []string) (model.Song, error) {
+	var song model.Song
+	var problems []string
+	for i, field := range fields {
+		if field.set == nil {
+			continue
+		}
+		if err := field.set(&song, strings.TrimSpace(record[i])); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", strings.TrimSpace(header[i]), err))
+		}
+	}
+	if len(problems) > 0 {
+		return song, errors.New(strings.Join(problems, "; "))
+	}
+	if song.GroupName == "" || song.SongTitle == "" {
+		return song, ErrSongRequired
+	}
+	return song, nil
+}
+
+// enrichSong fills the lyrics of song from the external API, and its
+// release date and link when the row left them empty.
+func (s *MainService) enrichSong(song *model.Song) error {
+	detail, err := s.fetchSongDetail(song.GroupName, song.SongTitle)
+	if err != nil {
+		return err
+	}
+	song.Lyrics = detail.Text
+	if song.YouTubeLink == "" {
+		song.YouTubeLink = detail.Link
+	}
+	if song.ReleaseDate == "" && detail.ReleaseDate != "" {
+		if _, _, err := model.ParseReleaseDate(detail.ReleaseDate); err != nil {
+			s.log.Warn("Ignoring unparseable release date from external API", logrus.Fields{"release_date": detail.ReleaseDate})
+		} else {
+			song.ReleaseDate = detail.ReleaseDate
+		}
+	}
+	return nil
+}
+
+// addBatch stores a batch in one transaction. When the batch fails, its
+// songs are retried one by one so that only the offending rows fail.
+func (s *MainService) addBatch(batch []pendingSong, report *model.ImportReport, actor model.Principal) {
+	if len(batch) == 0 {
+		return
+	}
+
+	songs := make([]model.Song, len(batch))
+	for i, pending := range batch {
+		songs[i] = pending.song
+	}
+
+	change := songChange(model.RevisionCreate, actor, 0)
+	ids, err := s.repo.AddSongs(songs, change)
+	if err != nil {
+		s.log.Warn("Import batch failed, adding its songs one by one", logrus.Fields{"error": err, "size": len(batch)})
+		ids = make([]int, len(batch))
+		for i, pending := range batch {
+			id, err := s.repo.AddSong(pending.song, change)
+			if err != nil {
+				row := &report.Rows[pending.row-1]
+				row.Status, row.Error = model.ImportFailed, err.Error()
+				continue
+			}
+			ids[i] = id
+		}
+	}
+
+	for i, pending := range batch {
+		if ids[i] == 0 {
+			continue
+		}
+		row := &report.Rows[pending.row-1]
+		row.Status, row.ID = model.ImportCreated, ids[i]
+		s.refreshSections(ids[i])
+	}
+}
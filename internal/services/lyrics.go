@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/linediff"
+	"music/pkg/lrc"
+
+	"github.com/sirupsen/logrus"
+)
+
+// diffContext is the number of unchanged lines around each change in the
+// unified lyrics diff.
+const diffContext = 3
+
+// maxDiffLines bounds the lines of each side of a lyrics diff; the time a
+// diff takes grows with the product of its size and the number of changes.
+const maxDiffLines = 5000
+
+// ErrDiffTooLarge means the lyrics of a revision have too many lines to be
+// compared.
+var ErrDiffTooLarge = fmt.Errorf("lyrics are too long to compare: more than %d lines", maxDiffLines)
+
+// lyricLine is one line of lyrics. Verses are separated by "\n\n" as in
+// GetSongText; the break between two verses is kept as a separator line so
+// that it only lines up with another verse break, never with an empty line
+// inside a verse.
+type lyricLine struct {
+	text      string
+	separator bool
+	verse     int
+	line      int
+}
+
+func lyricLines(lyrics string) []lyricLine {
+	var lines []lyricLine
+	if lyrics == "" {
+		return lines
+	}
+	for v, verse := range strings.Split(lyrics, "\n\n") {
+		if v > 0 {
+			lines = append(lines, lyricLine{separator: true, verse: v})
+		}
+		for l, text := range strings.Split(verse, "\n") {
+			lines = append(lines, lyricLine{text: text, verse: v + 1, line: l + 1})
+		}
+	}
+	return lines
+}
+
+func lyricTexts(lines []lyricLine) []string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.text
+	}
+	return texts
+}
+
+// DiffLyrics compares the lyrics of two revisions of a song. to may be 0 for
+// the latest revision.
+func (s *MainService) DiffLyrics(id, from, to int) (model.LyricsDiff, error) {
+	s.log.Info("Diffing song lyrics", logrus.Fields{"id": id, "from": from, "to": to})
+
+	old, err := s.revisions.GetRevision(id, from)
+	if err != nil {
+		return model.LyricsDiff{}, err
+	}
+	var cur model.SongRevision
+	if to == 0 {
+		latest, err := s.revisions.GetRevisions(id, 1, 0)
+		if err != nil {
+			return model.LyricsDiff{}, err
+		}
+		if len(latest) == 0 {
+			return model.LyricsDiff{}, repository.ErrRevisionNotFound
+		}
+		cur = latest[0]
+	} else if cur, err = s.revisions.GetRevision(id, to); err != nil {
+		return model.LyricsDiff{}, err
+	}
+
+	a, b := lyricLines(old.Song.Lyrics), lyricLines(cur.Song.Lyrics)
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		return model.LyricsDiff{}, ErrDiffTooLarge
+	}
+	edits := linediff.Diff(len(a), len(b), func(i, j int) bool {
+		return a[i].separator == b[j].separator && a[i].text == b[j].text
+	})
+
+	diff := model.LyricsDiff{
+		SongID: id,
+		From:   old.Revision,
+		To:     cur.Revision,
+		Hunks:  make([]model.LyricsDiffHunk, 0),
+		Unified: linediff.Unified(
+			fmt.Sprintf("revision %d", old.Revision),
+			fmt.Sprintf("revision %d", cur.Revision),
+			lyricTexts(a), lyricTexts(b), edits, diffContext,
+		),
+	}
+
+	// Group the script into hunks that never cross a verse break.
+	var hunk *model.LyricsDiffHunk
+	for _, e := range edits {
+		var line lyricLine
+		var kind model.DiffHunkType
+		switch e.Op {
+		case linediff.Equal:
+			line, kind = a[e.A], model.HunkUnchanged
+		case linediff.Delete:
+			line, kind = a[e.A], model.HunkRemoved
+		case linediff.Insert:
+			line, kind = b[e.B], model.HunkAdded
+		}
+		if line.separator {
+			hunk = nil
+			continue
+		}
+		switch kind {
+		case model.HunkAdded:
+			diff.Added++
+		case model.HunkRemoved:
+			diff.Removed++
+		}
+		if hunk == nil || hunk.Type != kind {
+			diff.Hunks = append(diff.Hunks, model.LyricsDiffHunk{Type: kind})
+			hunk = &diff.Hunks[len(diff.Hunks)-1]
+			if e.A >= 0 {
+				hunk.FromVerse, hunk.FromLine = a[e.A].verse, a[e.A].line
+			}
+			if e.B >= 0 {
+				hunk.ToVerse, hunk.ToLine = b[e.B].verse, b[e.B].line
+			}
+		}
+		hunk.Lines = append(hunk.Lines, line.text)
+	}
+
+	return diff, nil
+}
+
+// ErrEmptyLRC means an LRC document has no timed lines.
+var ErrEmptyLRC = errors.New("LRC document has no timed lines")
+
+// syncedLines converts parsed LRC lines for storage.
+func syncedLines(lines []lrc.Line) []model.SyncedLine {
+	synced := make([]model.SyncedLine, len(lines))
+	for i, line := range lines {
+		synced[i] = model.SyncedLine{
+			TimeMs: int(line.Time / time.Millisecond),
+			Time:   lrc.FormatTimestamp(line.Time),
+			Text:   line.Text,
+		}
+	}
+	return synced
+}
+
+// SetSyncedLyrics replaces the synced lyrics of a song with an LRC
+// document and returns the number of lines stored. The timestamps must
+// increase from line to line.
+func (s *MainService) SetSyncedLyrics(id int, r io.Reader) (int, error) {
+	s.log.Info("Setting synced lyrics", logrus.Fields{"id": id})
+
+	doc, err := lrc.Parse(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(doc.Lines) == 0 {
+		return 0, ErrEmptyLRC
+	}
+
+	if err := s.lyrics.SetSyncedLyrics(id, syncedLines(doc.Lines)); err != nil {
+		return 0, err
+	}
+	return len(doc.Lines), nil
+}
+
+// GetSyncedLyrics returns the synced lines of a song in playback order.
+func (s *MainService) GetSyncedLyrics(id int) ([]model.SyncedLine, error) {
+	s.log.Info("Getting synced lyrics", logrus.Fields{"id": id})
+
+	lines, err := s.lyrics.GetSyncedLyrics(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].Time = lrc.FormatTimestamp(time.Duration(lines[i].TimeMs) * time.Millisecond)
+	}
+	return lines, nil
+}
+
+// SyncedLyricsAt returns the line of a song being sung at the given moment
+// and the line after it.
+func (s *MainService) SyncedLyricsAt(id int, at time.Duration) (model.SyncedLyricsAt, error) {
+	lines, err := s.GetSyncedLyrics(id)
+	if err != nil {
+		return model.SyncedLyricsAt{}, err
+	}
+
+	position := model.SyncedLyricsAt{
+		AtMs: int(at / time.Millisecond),
+		At:   lrc.FormatTimestamp(at),
+	}
+	next := sort.Search(len(lines), func(i int) bool { return lines[i].TimeMs > position.AtMs })
+	if next > 0 {
+		position.Current = &lines[next-1]
+	}
+	if next < len(lines) {
+		position.Next = &lines[next]
+	}
+	return position, nil
+}
+
+// ExportSyncedLyrics writes the synced lyrics of a song to w as LRC, tagged
+// with its title and group.
+func (s *MainService) ExportSyncedLyrics(id int, w io.Writer) error {
+	s.log.Info("Exporting synced lyrics", logrus.Fields{"id": id})
+
+	song, err := s.repo.GetSongByID(id)
+	if err != nil {
+		return err
+	}
+	lines, err := s.lyrics.GetSyncedLyrics(id)
+	if err != nil {
+		return err
+	}
+
+	doc := lrc.Lyrics{
+		Tags: map[string]string{"ti": song.SongTitle, "ar": song.GroupName},
+	}
+	for _, line := range lines {
+		doc.Lines = append(doc.Lines, lrc.Line{Time: time.Duration(line.TimeMs) * time.Millisecond, Text: line.Text})
+	}
+	return lrc.Write(w, doc)
+}
+
+// DeleteSyncedLyrics removes the synced lyrics of a song, keeping its
+// plain lyrics.
+func (s *MainService) DeleteSyncedLyrics(id int) error {
+	s.log.Info("Deleting synced lyrics", logrus.Fields{"id": id})
+	return s.lyrics.DeleteSyncedLyrics(id)
+}
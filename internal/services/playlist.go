@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/logger"
+	"music/pkg/playlistfile"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrPlaylistFile = errors.New("invalid playlist file")
+
+// defaultPlaylistName names imported playlists when neither the request
+// nor the file does.
+const defaultPlaylistName = "Imported playlist"
+
+// PlaylistService manages playlists. songs is used to match the tracks of
+// imported playlist files.
+type PlaylistService struct {
+	repo  *repository.PlaylistRepository
+	songs repository.SongRepository
+	log   *logger.Logger
+}
+
+func NewPlaylistService(repo *repository.PlaylistRepository, songs repository.SongRepository, log *logger.Logger) *PlaylistService {
+	return &PlaylistService{
+		repo:  repo,
+		songs: songs,
+		log:   log,
+	}
+}
+
+func (s *PlaylistService) GetAllPlaylists(limit, offset int) ([]model.Playlist, error) {
+	s.log.Info("Getting playlists", logrus.Fields{"limit": limit, "offset": offset})
+	return s.repo.GetAllPlaylists(limit, offset)
+}
+
+func (s *PlaylistService) GetPlaylistByID(id int) (model.Playlist, error) {
+	s.log.Info("Getting playlist by id", logrus.Fields{"id": id})
+	return s.repo.GetPlaylistByID(id)
+}
+
+func (s *PlaylistService) AddPlaylist(playlist model.Playlist) (int, error) {
+	s.log.Info("Adding playlist", logrus.Fields{"name": playlist.Name})
+	return s.repo.AddPlaylist(playlist)
+}
+
+func (s *PlaylistService) UpdatePlaylist(id int, playlist model.Playlist) error {
+	s.log.Info("Updating playlist", logrus.Fields{"id": id, "name": playlist.Name})
+	return s.repo.UpdatePlaylist(id, playlist)
+}
+
+func (s *PlaylistService) DeletePlaylist(id int) error {
+	s.log.Info("Deleting playlist", logrus.Fields{"id": id})
+	return s.repo.DeletePlaylist(id)
+}
+
+func (s *PlaylistService) AddEntry(playlistID, songID, position int) (int, error) {
+	s.log.Info("Adding playlist entry", logrus.Fields{"playlist_id": playlistID, "song_id": songID, "position": position})
+	return s.repo.AddEntry(playlistID, songID, position)
+}
+
+func (s *PlaylistService) MoveEntry(playlistID, entryID, position int) error {
+	s.log.Info("Moving playlist entry", logrus.Fields{"playlist_id": playlistID, "entry_id": entryID, "position": position})
+	return s.repo.MoveEntry(playlistID, entryID, position)
+}
+
+func (s *PlaylistService) RemoveEntry(playlistID, entryID int) error {
+	s.log.Info("Removing playlist entry", logrus.Fields{"playlist_id": playlistID, "entry_id": entryID})
+	return s.repo.RemoveEntry(playlistID, entryID)
+}
+
+// ImportPlaylist creates a playlist from an M3U, M3U8 or XSPF file. Tracks
+// are matched to songs by artist and title; unmatched tracks are reported
+// and left out. The playlist is named name, or after the file when name is
+// empty.
+func (s *PlaylistService) ImportPlaylist(r io.Reader, format playlistfile.Format, name string) (model.PlaylistImport, error) {
+	s.log.Info("Importing playlist", logrus.Fields{"format": format, "name": name})
+
+	file, err := playlistfile.Parse(r, format)
+	if err != nil {
+		return model.PlaylistImport{}, fmt.Errorf("%w: %w", ErrPlaylistFile, err)
+	}
+
+	report := model.PlaylistImport{
+		Name:      strings.TrimSpace(name),
+		Matched:   make([]model.PlaylistImportEntry, 0),
+		Unmatched: make([]model.PlaylistImportEntry, 0),
+	}
+	if report.Name == "" {
+		report.Name = file.Title
+	}
+	if report.Name == "" {
+		report.Name = defaultPlaylistName
+	}
+
+	var songIDs []int
+	for i, track := range file.Tracks {
+		entry := model.PlaylistImportEntry{
+			Index:    i + 1,
+			Artist:   track.Artist,
+			Title:    track.Title,
+			Location: track.Location,
+		}
+		if track.Artist == "" || track.Title == "" {
+			report.Unmatched = append(report.Unmatched, entry)
+			continue
+		}
+		song, err := s.songs.FindSong(track.Artist, track.Title)
+		if errors.Is(err, repository.ErrSongNotFound) {
+			report.Unmatched = append(report.Unmatched, entry)
+			continue
+		}
+		if err != nil {
+			return model.PlaylistImport{}, err
+		}
+		entry.SongID = song.ID
+		report.Matched = append(report.Matched, entry)
+		songIDs = append(songIDs, song.ID)
+	}
+
+	report.PlaylistID, err = s.repo.AddPlaylistWithSongs(model.Playlist{Name: report.Name}, songIDs)
+	if err != nil {
+		return model.PlaylistImport{}, err
+	}
+
+	s.log.Info("Imported playlist", logrus.Fields{
+		"id":        report.PlaylistID,
+		"matched":   len(report.Matched),
+		"unmatched": len(report.Unmatched),
+	})
+	return report, nil
+}
+
+// ExportPlaylist writes the entries of a playlist to w in format, with the
+// YouTube link of each song as its location.
+func (s *PlaylistService) ExportPlaylist(w io.Writer, id int, format playlistfile.Format) error {
+	s.log.Info("Exporting playlist", logrus.Fields{"id": id, "format": format})
+
+	playlist, err := s.repo.GetPlaylistByID(id)
+	if err != nil {
+		return err
+	}
+
+	out, err := playlistfile.NewWriter(w, format, playlist.Name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range playlist.Entries {
+		if err := out.Write(songTrack(entry.Song)); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// songTrack describes a song as a playlist track.
+func songTrack(song model.Song) playlistfile.Track {
+	return playlistfile.Track{
+		Artist:   song.GroupName,
+		Title:    song.SongTitle,
+		Location: song.YouTubeLink,
+		Duration: -1,
+	}
+}
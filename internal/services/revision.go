@@ -0,0 +1,47 @@
+package services
+
+import (
+	"music/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// songChange describes a change for the repository to record as a revision
+// of the song it writes.
+func songChange(action model.RevisionAction, actor model.Principal, source int) model.SongRevision {
+	return model.SongRevision{
+		Action:         action,
+		SourceRevision: source,
+		Actor:          actor.Actor(),
+		ActorUserID:    actor.UserID,
+		ActorAPIKeyID:  actor.APIKeyID,
+	}
+}
+
+// GetRevisions lists the revisions of a song, newest first.
+func (s *MainService) GetRevisions(id, limit, offset int) ([]model.SongRevision, error) {
+	s.log.Info("Getting song revisions", logrus.Fields{"id": id, "limit": limit, "offset": offset})
+	return s.revisions.GetRevisions(id, limit, offset)
+}
+
+func (s *MainService) GetRevision(id, revision int) (model.SongRevision, error) {
+	s.log.Info("Getting song revision", logrus.Fields{"id": id, "revision": revision})
+	return s.revisions.GetRevision(id, revision)
+}
+
+// RevertSong restores the fields of a song from one of its revisions. The
+// revert is itself recorded as a new revision. A non-zero version makes it
+// conditional like UpdateSong.
+func (s *MainService) RevertSong(id, revision, version int, actor model.Principal) error {
+	s.log.Info("Reverting song", logrus.Fields{"id": id, "revision": revision, "version": version})
+
+	rev, err := s.revisions.GetRevision(id, revision)
+	if err != nil {
+		return err
+	}
+	if err := s.updateSong(id, rev.Song, version, songChange(model.RevisionRevert, actor, revision)); err != nil {
+		return err
+	}
+	s.refreshSections(id)
+	return nil
+}
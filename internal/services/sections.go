@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+
+	"music/internal/model"
+	"music/pkg/lyricsection"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrSectionType = errors.New("type must be intro, verse, chorus, bridge or outro")
+
+// songSections splits the lyrics of a song into sections.
+func songSections(lyrics string) []model.LyricsSection {
+	parsed := lyricsection.Parse(lyrics)
+	sections := make([]model.LyricsSection, len(parsed))
+	for i, section := range parsed {
+		sections[i] = model.LyricsSection{
+			Position: i + 1,
+			Type:     string(section.Type),
+			Number:   section.Number,
+			Label:    section.Label(),
+			Lines:    section.Lines,
+			RepeatOf: section.RepeatOf,
+		}
+	}
+	return sections
+}
+
+// storeSections stores the sections of song as of its current version. The
+// song itself is already saved, so a failure is logged rather than
+// returned; GetSongText splits the lyrics again when it finds the stored
+// sections out of date.
+func (s *MainService) storeSections(song model.Song) []model.LyricsSection {
+	sections := songSections(song.Lyrics)
+	if err := s.lyrics.SetSections(song.ID, song.Version, sections); err != nil {
+		s.log.Error("Failed to store lyrics sections", logrus.Fields{"error": err, "song_id": song.ID})
+	}
+	return sections
+}
+
+// refreshSections stores the sections of a song after a write. They are a
+// cache of the lyrics, so a failure is logged rather than returned.
+func (s *MainService) refreshSections(id int) {
+	song, err := s.repo.GetSongByID(id)
+	if err != nil {
+		s.log.Error("Failed to read song for lyrics sections", logrus.Fields{"error": err, "song_id": id})
+		return
+	}
+	s.storeSections(song)
+}
+
+// GetSongText returns a page of the sections of a song's lyrics, filtered
+// by type. With q.Collapse, a section sung again later is listed once, with
+// the number of times it is sung in Repeats.
+func (s *MainService) GetSongText(id int, q model.SectionQuery) (model.SongText, error) {
+	s.log.Info("Getting a song text", logrus.Fields{"id": id, "types": q.Types, "collapse": q.Collapse})
+
+	types := make(map[string]bool)
+	for _, name := range q.Types {
+		t, ok := lyricsection.ParseType(name)
+		if !ok {
+			return model.SongText{}, ErrSectionType
+		}
+		types[string(t)] = true
+	}
+
+	song, err := s.repo.GetSongByID(id)
+	if err != nil {
+		return model.SongText{}, err
+	}
+	sections, version, err := s.lyrics.GetSections(id)
+	if err != nil {
+		return model.SongText{}, err
+	}
+	if version != song.Version || (len(sections) == 0 && song.Lyrics != "") {
+		sections = s.storeSections(song)
+	}
+	for i := range sections {
+		sections[i].Label = lyricsection.Section{
+			Type:   lyricsection.Type(sections[i].Type),
+			Number: sections[i].Number,
+		}.Label()
+	}
+
+	if q.Collapse {
+		collapsed := sections[:0:0]
+		index := make(map[int]int)
+		for _, section := range sections {
+			if section.RepeatOf != 0 {
+				collapsed[index[section.RepeatOf]].Repeats++
+				continue
+			}
+			section.Repeats = 1
+			index[section.Position] = len(collapsed)
+			collapsed = append(collapsed, section)
+		}
+		sections = collapsed
+	}
+
+	selected := make([]model.LyricsSection, 0, len(sections))
+	for _, section := range sections {
+		if len(types) == 0 || types[section.Type] {
+			selected = append(selected, section)
+		}
+	}
+
+	text := model.SongText{
+		SongID:   id,
+		Page:     q.Page,
+		PerPage:  q.PerPage,
+		Total:    len(selected),
+		Sections: []model.LyricsSection{},
+	}
+	start := (q.Page - 1) * q.PerPage
+	if start < len(selected) {
+		end := start + q.PerPage
+		if end > len(selected) {
+			end = len(selected)
+		}
+		text.Sections = selected[start:end]
+	}
+	return text, nil
+}
+
+// FormatSections renders sections as plain lyrics with a "[Label]" line
+// before each section.
+func FormatSections(sections []model.LyricsSection) string {
+	parsed := make([]lyricsection.Section, len(sections))
+	for i, section := range sections {
+		parsed[i] = lyricsection.Section{
+			Type:   lyricsection.Type(section.Type),
+			Number: section.Number,
+			Lines:  section.Lines,
+		}
+	}
+	return lyricsection.Format(parsed)
+}
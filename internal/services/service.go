@@ -2,64 +2,82 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"music/internal/model"
 	"music/internal/repository"
 	"music/pkg/config"
 	"music/pkg/logger"
-	"net/http"
-	"net/url"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	ErrSongRequired  = errors.New("group and song title are required")
+	ErrReadOnlyField = errors.New("id, artist_id, created_at, version and updated_at cannot be changed")
+	ErrInvalidSong   = errors.New("patched document is not a valid song")
+)
+
+// MainService manages songs and their lyrics. Every change to a song is
+// recorded as a revision attributed to the calling principal.
 type MainService struct {
-	repo   *repository.MainRepository
-	client *http.Client
-	cfg    *config.Config
-	log    *logger.Logger
+	repo       repository.SongRepository
+	revisions  repository.RevisionStore
+	lyrics     repository.LyricsStore
+	enrichment *EnrichmentClient
+	cfg        *config.Config
+	log        *logger.Logger
 }
 
-func NewMainService(repo *repository.MainRepository, client *http.Client, cfg *config.Config, log *logger.Logger) *MainService {
+func NewMainService(repo repository.SongRepository, revisions repository.RevisionStore, lyrics repository.LyricsStore, enrichment *EnrichmentClient, cfg *config.Config, log *logger.Logger) *MainService {
 	return &MainService{
-		repo:   repo,
-		client: client,
-		cfg:    cfg,
-		log:    log,
+		repo:       repo,
+		revisions:  revisions,
+		lyrics:     lyrics,
+		enrichment: enrichment,
+		cfg:        cfg,
+		log:        log,
 	}
 }
 
-func (s *MainService) GetAllSongs(filters map[string]string, limit, offset int) ([]model.Song, error) {
-    s.log.Info("Getting filtered songs", logrus.Fields{
-        "filters": filters,
-        "limit":   limit,
-        "offset":  offset,
-    })
-    
-    return s.repo.GetAllSongs(filters, limit, offset)
-}
-
-func (s *MainService) AddSong(song model.Song) (int, error) {
-	s.log.Info("Adding song", logrus.Fields{"group": song.GroupName, "song": song.SongTitle})
-	resp, err := s.client.Get(fmt.Sprintf("%s/info?group=%s&song=%s", s.cfg.ExternalAPI, url.QueryEscape(song.GroupName), url.QueryEscape(song.SongTitle)))
+// GetAllSongs returns a page of songs. A full page carries the cursor of
+// the next one.
+func (s *MainService) GetAllSongs(q model.SongQuery) (model.SongPage, error) {
+	s.log.Info("Getting filtered songs", logrus.Fields{
+		"filters": q.Filters,
+		"match":   q.Match,
+		"cursor":  q.Cursor,
+		"limit":   q.Limit,
+		"offset":  q.Offset,
+	})
+
+	songs, err := s.repo.GetAllSongs(q)
 	if err != nil {
-		return 0, err
+		return model.SongPage{}, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("external API error: status %d", resp.StatusCode)
+	page := model.SongPage{Songs: songs}
+	if q.Limit > 0 && len(songs) == q.Limit {
+		page.NextCursor = repository.NextSongCursor(q, songs[len(songs)-1])
 	}
+	return page, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
-	}
+func (s *MainService) SearchSongs(query string, limit, offset int) ([]model.SongSearchResult, error) {
+	s.log.Info("Searching songs", logrus.Fields{
+		"query":  query,
+		"limit":  limit,
+		"offset": offset,
+	})
 
-	var songDetail model.SongDetail
-	err = json.Unmarshal(body, &songDetail)
+	return s.repo.SearchSongs(query, limit, offset)
+}
+
+func (s *MainService) AddSong(song model.Song, actor model.Principal) (int, error) {
+	s.log.Info("Adding song", logrus.Fields{"group": song.GroupName, "song": song.SongTitle})
+	songDetail, err := s.fetchSongDetail(song.GroupName, song.SongTitle)
 	if err != nil {
 		return 0, err
 	}
@@ -67,53 +85,126 @@ func (s *MainService) AddSong(song model.Song) (int, error) {
 	song.Lyrics = songDetail.Text
 	song.ReleaseDate = songDetail.ReleaseDate
 	song.YouTubeLink = songDetail.Link
+	if song.ReleaseDate != "" {
+		if _, _, err := model.ParseReleaseDate(song.ReleaseDate); err != nil {
+			s.log.Warn("Ignoring unparseable release date from external API", logrus.Fields{"release_date": song.ReleaseDate})
+			song.ReleaseDate = ""
+		}
+	}
 
-	id, err := s.repo.AddSong(song)
+	id, err := s.repo.AddSong(song, songChange(model.RevisionCreate, actor, 0))
 	if err != nil {
 		return 0, err
 	}
+	s.refreshSections(id)
 	return id, nil
 }
 
-func (s *MainService) UpdateSong(id int, song model.Song) error {
-	s.log.Info("Updating song", logrus.Fields{"id": id, "group": song.GroupName, "song": song.SongTitle})
-	return s.repo.UpdateSong(id, song)
+// fetchSongDetail asks the external API for the lyrics, release date and
+// link of a song.
+func (s *MainService) fetchSongDetail(group, song string) (model.SongDetail, error) {
+	return s.enrichment.SongDetail(group, song)
 }
 
-func (s *MainService) DeleteSong(id int) error {
-	s.log.Info("Deleting song", logrus.Fields{"id": id})
-	return s.repo.DeleteSong(id)
+// EnrichmentStatus reports the state of the external API client.
+func (s *MainService) EnrichmentStatus() model.EnrichmentStatus {
+	return s.enrichment.Status()
 }
 
-func (s *MainService) GetSongByID(id int) (model.Song, error) {
-	s.log.Info("Gettting song by id", logrus.Fields{"id": id})
-	return s.repo.GetSongByID(id)
+// UpdateSong replaces all fields of a song; group and title are required.
+// A non-zero version makes the update conditional on it.
+func (s *MainService) UpdateSong(id int, song model.Song, version int, actor model.Principal) error {
+	s.log.Info("Updating song", logrus.Fields{"id": id, "group": song.GroupName, "song": song.SongTitle, "version": version})
+	if err := s.updateSong(id, song, version, songChange(model.RevisionUpdate, actor, 0)); err != nil {
+		return err
+	}
+	s.refreshSections(id)
+	return nil
+}
+
+func (s *MainService) updateSong(id int, song model.Song, version int, change model.SongRevision) error {
+	if strings.TrimSpace(song.GroupName) == "" || strings.TrimSpace(song.SongTitle) == "" {
+		return ErrSongRequired
+	}
+	return s.repo.UpdateSong(id, song, version, change)
 }
 
-func (s *MainService) GetSongText(id, page, perPage int) (string, error) {
-	s.log.Info("Getting a song text", logrus.Fields{"id": id})
+// PatchSong applies patch to the JSON form of a song and stores the result.
+// patch is a JSON Merge Patch or JSON Patch applier from pkg/jsonpatch. The
+// update is conditional on the version the patch was applied to, so
+// concurrent changes are never overwritten.
+func (s *MainService) PatchSong(id int, version int, actor model.Principal, patch func(doc []byte) ([]byte, error)) error {
+	s.log.Info("Patching song", logrus.Fields{"id": id, "version": version})
 
 	song, err := s.repo.GetSongByID(id)
 	if err != nil {
-		s.log.Error("get song text", logrus.Fields{"error": err.Error()})
+		return err
+	}
+	if version != 0 && song.Version != version {
+		return repository.ErrVersionMismatch
+	}
+	doc, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+	doc, err = patch(doc)
+	if err != nil {
+		return err
 	}
 
-	verses := strings.Split(song.Lyrics, "\n\n")
-	if len(verses) == 0 {
-		return "", nil
+	var patched model.Song
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSong, err)
 	}
+	// release_precision follows release_date and is recomputed on update.
+	if patched.ID != song.ID || patched.ArtistID != song.ArtistID || !patched.CreatedAt.Equal(song.CreatedAt) ||
+		patched.Version != song.Version || !patched.UpdatedAt.Equal(song.UpdatedAt) {
+		return ErrReadOnlyField
+	}
+	if err := s.updateSong(id, patched, song.Version, songChange(model.RevisionUpdate, actor, 0)); err != nil {
+		return err
+	}
+	s.refreshSections(id)
+	return nil
+}
 
-	start := (page - 1) * perPage
-	end := start + perPage
+// DeleteSong moves a song to the trash. Its revision holds the song as it
+// was deleted.
+func (s *MainService) DeleteSong(id int, version int, actor model.Principal) error {
+	s.log.Info("Deleting song", logrus.Fields{"id": id, "version": version})
+	return s.repo.DeleteSong(id, version, songChange(model.RevisionDelete, actor, 0))
+}
 
-	if start >= len(verses) {
-		return "", nil
-	}
+// GetTrash lists deleted songs, most recently deleted first.
+func (s *MainService) GetTrash(limit, offset int) ([]model.Song, error) {
+	s.log.Info("Getting trash", logrus.Fields{"limit": limit, "offset": offset})
+	return s.repo.GetDeletedSongs(limit, offset)
+}
 
-	if end > len(verses) {
-		end = len(verses)
+func (s *MainService) RestoreSong(id int, actor model.Principal) error {
+	s.log.Info("Restoring song", logrus.Fields{"id": id})
+	if err := s.repo.RestoreSong(id, songChange(model.RevisionRestore, actor, 0)); err != nil {
+		return err
 	}
+	s.refreshSections(id)
+	return nil
+}
+
+// PurgeTrash permanently removes songs that have been in the trash longer
+// than the configured retention and returns how many were removed.
+func (s *MainService) PurgeTrash() (int, error) {
+	before := time.Now().Add(-s.cfg.Trash.Retention)
+	s.log.Info("Purging trash", logrus.Fields{"before": before})
 
-	return strings.Join(verses[start:end], "\n\n"), nil
+	purged, err := s.repo.PurgeSongs(before)
+	if err != nil {
+		return 0, err
+	}
+	s.log.Info("Trash purged", logrus.Fields{"purged": purged})
+	return purged, nil
+}
 
+func (s *MainService) GetSongByID(id int) (model.Song, error) {
+	s.log.Info("Gettting song by id", logrus.Fields{"id": id})
+	return s.repo.GetSongByID(id)
 }
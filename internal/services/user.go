@@ -0,0 +1,41 @@
+package services
+
+import (
+	"music/internal/model"
+	"music/internal/repository"
+	"music/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type UserService struct {
+	repo *repository.UserRepository
+	log  *logger.Logger
+}
+
+func NewUserService(repo *repository.UserRepository, log *logger.Logger) *UserService {
+	return &UserService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *UserService) GetAllUsers(limit, offset int) ([]model.User, error) {
+	s.log.Info("Getting users", logrus.Fields{"limit": limit, "offset": offset})
+	return s.repo.GetAllUsers(limit, offset)
+}
+
+func (s *UserService) GetUserByID(id int) (model.User, error) {
+	s.log.Info("Getting user by id", logrus.Fields{"id": id})
+	return s.repo.GetUserByID(id)
+}
+
+func (s *UserService) SetUserRole(id int, role model.Role) error {
+	s.log.Info("Changing user role", logrus.Fields{"id": id, "role": role})
+	return s.repo.SetUserRole(id, role)
+}
+
+func (s *UserService) DeleteUser(id int) error {
+	s.log.Info("Deleting user", logrus.Fields{"id": id})
+	return s.repo.DeleteUser(id)
+}
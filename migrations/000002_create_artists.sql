@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS artists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL UNIQUE,
+    country VARCHAR(100),
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO artists (name, normalized_name)
+SELECT DISTINCT ON (normalized_name) name, normalized_name
+FROM (
+    SELECT id,
+           REGEXP_REPLACE(BTRIM(group_name), '\s+', ' ', 'g') AS name,
+           LOWER(REGEXP_REPLACE(BTRIM(group_name), '\s+', ' ', 'g')) AS normalized_name
+    FROM songs
+) s
+ORDER BY normalized_name, id;
+
+ALTER TABLE songs ADD COLUMN artist_id INTEGER REFERENCES artists(id);
+
+UPDATE songs
+SET artist_id = a.id
+FROM artists a
+WHERE a.normalized_name = LOWER(REGEXP_REPLACE(BTRIM(songs.group_name), '\s+', ' ', 'g'));
+
+ALTER TABLE songs ALTER COLUMN artist_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_songs_group;
+ALTER TABLE songs DROP COLUMN group_name;
+CREATE INDEX idx_songs_artist ON songs(artist_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN group_name VARCHAR(255);
+
+UPDATE songs
+SET group_name = a.name
+FROM artists a
+WHERE a.id = songs.artist_id;
+
+ALTER TABLE songs ALTER COLUMN group_name SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_songs_artist;
+ALTER TABLE songs DROP COLUMN artist_id;
+CREATE INDEX idx_songs_group ON songs(group_name);
+
+DROP TABLE IF EXISTS artists;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS albums (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    artist_id INTEGER NOT NULL REFERENCES artists(id),
+    release_date VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_albums_artist ON albums(artist_id);
+
+ALTER TABLE songs ADD COLUMN album_id INTEGER REFERENCES albums(id) ON DELETE SET NULL;
+ALTER TABLE songs ADD COLUMN disc_number INTEGER;
+ALTER TABLE songs ADD COLUMN track_number INTEGER;
+
+CREATE UNIQUE INDEX idx_songs_album_track ON songs(album_id, disc_number, track_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_songs_album_track;
+ALTER TABLE songs DROP COLUMN track_number;
+ALTER TABLE songs DROP COLUMN disc_number;
+ALTER TABLE songs DROP COLUMN album_id;
+
+DROP TABLE IF EXISTS albums;
+-- +goose StatementEnd
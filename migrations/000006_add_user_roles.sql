@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'viewer';
+-- +goose StatementEnd
+
+-- The oldest account administers existing installations.
+-- +goose StatementBegin
+UPDATE users SET role = 'admin' WHERE id = (SELECT MIN(id) FROM users);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Titles weigh most, then the group, then lyrics. The 'simple' configuration
+-- does not stem, so lyrics in any language are indexed the same way.
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(song_title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(lyrics, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_songs_search ON songs USING GIN (search_vector);
+
+ALTER TABLE artists ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', name), 'B')
+) STORED;
+
+CREATE INDEX idx_artists_search ON artists USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_artists_search;
+ALTER TABLE artists DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS idx_songs_search;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Trigram indexes serve the fuzzy (%) match mode and also speed up the
+-- prefix and contains modes (LIKE).
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_songs_title_trgm ON songs USING GIN (LOWER(song_title) gin_trgm_ops);
+CREATE INDEX idx_artists_name_trgm ON artists USING GIN (normalized_name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_artists_name_trgm;
+DROP INDEX IF EXISTS idx_songs_title_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- Release dates come from the external API as dd.mm.yyyy text, sometimes
+-- only as mm.yyyy or yyyy. The precision keeps what was known; partial
+-- dates are stored as the first day of their month or year. Anything else
+-- cannot be placed on a calendar and is cleared, including values in one of
+-- the formats that name no real day, such as 31.02.2020; those are reported
+-- with a warning in the server log.
+-- +goose StatementBegin
+CREATE FUNCTION pg_temp.release_to_date(song_id INTEGER, value TEXT, format TEXT) RETURNS DATE AS $$
+DECLARE
+    parsed DATE;
+BEGIN
+    parsed := to_date(value, format);
+    -- Some servers roll an impossible day over into the next month
+    -- instead of failing.
+    IF to_char(parsed, format) <> value THEN
+        RAISE EXCEPTION USING ERRCODE = 'datetime_field_overflow';
+    END IF;
+    RETURN parsed;
+EXCEPTION WHEN data_exception THEN
+    RAISE WARNING 'song %: clearing release date "%", it is not a calendar date', song_id, value;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE songs ADD COLUMN release_precision VARCHAR(5);
+
+UPDATE songs SET release_precision = CASE
+    WHEN release_date ~ '^\d{2}\.\d{2}\.\d{4}$' THEN 'day'
+    WHEN release_date ~ '^\d{4}-\d{2}-\d{2}$' THEN 'day'
+    WHEN release_date ~ '^\d{2}\.\d{4}$' THEN 'month'
+    WHEN release_date ~ '^\d{4}$' THEN 'year'
+END;
+
+ALTER TABLE songs ALTER COLUMN release_date TYPE DATE USING CASE
+    WHEN release_date ~ '^\d{2}\.\d{2}\.\d{4}$' THEN pg_temp.release_to_date(id, release_date, 'DD.MM.YYYY')
+    WHEN release_date ~ '^\d{4}-\d{2}-\d{2}$' THEN pg_temp.release_to_date(id, release_date, 'YYYY-MM-DD')
+    WHEN release_date ~ '^\d{2}\.\d{4}$' THEN pg_temp.release_to_date(id, release_date, 'MM.YYYY')
+    WHEN release_date ~ '^\d{4}$' THEN pg_temp.release_to_date(id, release_date, 'YYYY')
+END;
+
+UPDATE songs SET release_precision = NULL WHERE release_date IS NULL;
+
+DROP FUNCTION pg_temp.release_to_date(INTEGER, TEXT, TEXT);
+
+CREATE INDEX idx_songs_release_date ON songs(release_date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_songs_release_date;
+
+ALTER TABLE songs ALTER COLUMN release_date TYPE VARCHAR(50) USING CASE release_precision
+    WHEN 'year' THEN to_char(release_date, 'YYYY')
+    WHEN 'month' THEN to_char(release_date, 'MM.YYYY')
+    ELSE to_char(release_date, 'DD.MM.YYYY')
+END;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS release_precision;
+-- +goose StatementEnd
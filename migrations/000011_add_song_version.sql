@@ -0,0 +1,13 @@
+-- +goose Up
+-- version is bumped by every update and backs the ETag of a song.
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE songs ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+UPDATE songs SET updated_at = created_at WHERE created_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
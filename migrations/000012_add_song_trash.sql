@@ -0,0 +1,23 @@
+-- +goose Up
+-- Deleted songs are kept in the trash until purged. A trashed song no longer
+-- holds its album track position.
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX idx_songs_deleted_at ON songs(deleted_at);
+
+DROP INDEX IF EXISTS idx_songs_album_track;
+CREATE UNIQUE INDEX idx_songs_album_track ON songs(album_id, disc_number, track_number)
+    WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Without deleted_at nothing would hide trashed songs, so they are purged.
+-- +goose StatementBegin
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_songs_album_track;
+CREATE UNIQUE INDEX idx_songs_album_track ON songs(album_id, disc_number, track_number);
+
+DROP INDEX IF EXISTS idx_songs_deleted_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
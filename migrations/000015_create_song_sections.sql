@@ -0,0 +1,22 @@
+-- +goose Up
+-- The lyrics of a song split into labeled sections, one row per section in
+-- order. lines holds the lines of the section joined by newlines and
+-- repeat_of the position of an earlier section with the same text.
+-- song_version is the version of the song the sections were taken from.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS song_sections (
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    number INTEGER NOT NULL DEFAULT 0,
+    lines TEXT NOT NULL,
+    repeat_of INTEGER,
+    song_version INTEGER NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_sections;
+-- +goose StatementEnd
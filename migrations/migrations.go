@@ -0,0 +1,9 @@
+// Package migrations embeds the goose migration files so the binary can apply
+// them without access to the source tree. PostgreSQL migrations live in the
+// root of FS, SQLite ones under sqlite/.
+package migrations
+
+import "embed"
+
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
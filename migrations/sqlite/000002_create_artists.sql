@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS artists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL UNIQUE,
+    country VARCHAR(100),
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- squeeze_spaces is registered by internal/db, like the Unicode-aware lower().
+INSERT INTO artists (name, normalized_name)
+SELECT (
+           SELECT SQUEEZE_SPACES(s2.group_name)
+           FROM songs s2
+           WHERE LOWER(SQUEEZE_SPACES(s2.group_name)) = n.normalized_name
+           ORDER BY s2.id
+           LIMIT 1
+       ),
+       n.normalized_name
+FROM (SELECT DISTINCT LOWER(SQUEEZE_SPACES(group_name)) AS normalized_name FROM songs) n;
+
+ALTER TABLE songs ADD COLUMN artist_id INTEGER REFERENCES artists(id);
+
+UPDATE songs
+SET artist_id = (SELECT a.id FROM artists a WHERE a.normalized_name = LOWER(SQUEEZE_SPACES(songs.group_name)));
+
+DROP INDEX IF EXISTS idx_songs_group;
+ALTER TABLE songs DROP COLUMN group_name;
+CREATE INDEX idx_songs_artist ON songs(artist_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE songs_down (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_name VARCHAR(255) NOT NULL,
+    song_title VARCHAR(255) NOT NULL,
+    release_date VARCHAR(50),
+    lyrics TEXT,
+    youtube_link VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO songs_down (id, group_name, song_title, release_date, lyrics, youtube_link, created_at)
+SELECT s.id, a.name, s.song_title, s.release_date, s.lyrics, s.youtube_link, s.created_at
+FROM songs s
+JOIN artists a ON a.id = s.artist_id;
+
+DROP TABLE songs;
+ALTER TABLE songs_down RENAME TO songs;
+CREATE INDEX idx_songs_group ON songs(group_name);
+CREATE INDEX idx_songs_title ON songs(song_title);
+
+DROP TABLE IF EXISTS artists;
+-- +goose StatementEnd
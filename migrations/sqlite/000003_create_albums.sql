@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    artist_id INTEGER NOT NULL REFERENCES artists(id),
+    release_date VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_albums_artist ON albums(artist_id);
+
+ALTER TABLE songs ADD COLUMN album_id INTEGER REFERENCES albums(id) ON DELETE SET NULL;
+ALTER TABLE songs ADD COLUMN disc_number INTEGER;
+ALTER TABLE songs ADD COLUMN track_number INTEGER;
+
+CREATE UNIQUE INDEX idx_songs_album_track ON songs(album_id, disc_number, track_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE songs_down (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    artist_id INTEGER REFERENCES artists(id),
+    song_title VARCHAR(255) NOT NULL,
+    release_date VARCHAR(50),
+    lyrics TEXT,
+    youtube_link VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO songs_down (id, artist_id, song_title, release_date, lyrics, youtube_link, created_at)
+SELECT id, artist_id, song_title, release_date, lyrics, youtube_link, created_at
+FROM songs;
+
+DROP TABLE songs;
+ALTER TABLE songs_down RENAME TO songs;
+CREATE INDEX idx_songs_title ON songs(song_title);
+CREATE INDEX idx_songs_artist ON songs(artist_id);
+
+DROP TABLE IF EXISTS albums;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- SQLite has no tsvector; an FTS5 table keyed by song id is kept in sync by
+-- triggers instead.
+-- +goose StatementBegin
+CREATE VIRTUAL TABLE songs_fts USING fts5(
+    song_title,
+    group_name,
+    lyrics,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO songs_fts (rowid, song_title, group_name, lyrics)
+SELECT s.id, s.song_title, a.name, COALESCE(s.lyrics, '')
+FROM songs s JOIN artists a ON a.id = s.artist_id;
+
+CREATE TRIGGER songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts (rowid, song_title, group_name, lyrics)
+    VALUES (new.id, new.song_title, (SELECT name FROM artists WHERE id = new.artist_id), COALESCE(new.lyrics, ''));
+END;
+
+CREATE TRIGGER songs_fts_update AFTER UPDATE ON songs BEGIN
+    DELETE FROM songs_fts WHERE rowid = old.id;
+    INSERT INTO songs_fts (rowid, song_title, group_name, lyrics)
+    VALUES (new.id, new.song_title, (SELECT name FROM artists WHERE id = new.artist_id), COALESCE(new.lyrics, ''));
+END;
+
+CREATE TRIGGER songs_fts_delete AFTER DELETE ON songs BEGIN
+    DELETE FROM songs_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER artists_fts_update AFTER UPDATE OF name ON artists BEGIN
+    UPDATE songs_fts SET group_name = new.name
+    WHERE rowid IN (SELECT id FROM songs WHERE artist_id = new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS artists_fts_update;
+DROP TRIGGER IF EXISTS songs_fts_delete;
+DROP TRIGGER IF EXISTS songs_fts_update;
+DROP TRIGGER IF EXISTS songs_fts_insert;
+DROP TABLE IF EXISTS songs_fts;
+-- +goose StatementEnd
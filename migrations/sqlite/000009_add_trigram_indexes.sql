@@ -0,0 +1,6 @@
+-- +goose Up
+-- SQLite has no pg_trgm. similarity() is registered by the application
+-- (internal/db/sqlite.go) and fuzzy matches scan the table; this version
+-- only keeps the numbering in step with the PostgreSQL migrations.
+
+-- +goose Down
@@ -0,0 +1,49 @@
+-- +goose Up
+-- SQLite has no DATE type. Rebuilding songs to change the declared type
+-- would cascade into playlist_entries, so release_date keeps its column and
+-- now holds ISO-8601 text (yyyy-mm-dd), which compares chronologically.
+-- Partial dates are stored as the first day of their month or year; the
+-- precision keeps what was known. Anything else is cleared, including values
+-- in one of the formats that name no real day, such as 31.02.2020.
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN release_precision VARCHAR(5);
+
+UPDATE songs SET
+    release_date = CASE
+        WHEN release_date GLOB '[0-9][0-9].[0-9][0-9].[0-9][0-9][0-9][0-9]'
+            THEN SUBSTR(release_date, 7, 4) || '-' || SUBSTR(release_date, 4, 2) || '-' || SUBSTR(release_date, 1, 2)
+        WHEN release_date GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]'
+            THEN release_date
+        WHEN release_date GLOB '[0-9][0-9].[0-9][0-9][0-9][0-9]'
+            THEN SUBSTR(release_date, 4, 4) || '-' || SUBSTR(release_date, 1, 2) || '-01'
+        WHEN release_date GLOB '[0-9][0-9][0-9][0-9]'
+            THEN release_date || '-01-01'
+    END,
+    release_precision = CASE
+        WHEN release_date GLOB '[0-9][0-9].[0-9][0-9].[0-9][0-9][0-9][0-9]' THEN 'day'
+        WHEN release_date GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]' THEN 'day'
+        WHEN release_date GLOB '[0-9][0-9].[0-9][0-9][0-9][0-9]' THEN 'month'
+        WHEN release_date GLOB '[0-9][0-9][0-9][0-9]' THEN 'year'
+    END;
+
+-- date() rolls an impossible day over into the next month and rejects an
+-- impossible month, so such dates do not read back unchanged.
+UPDATE songs SET release_date = NULL, release_precision = NULL
+WHERE release_date IS NOT NULL AND date(release_date) IS NOT release_date;
+
+CREATE INDEX idx_songs_release_date ON songs(release_date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_songs_release_date;
+
+UPDATE songs SET release_date = CASE release_precision
+    WHEN 'year' THEN SUBSTR(release_date, 1, 4)
+    WHEN 'month' THEN SUBSTR(release_date, 6, 2) || '.' || SUBSTR(release_date, 1, 4)
+    ELSE SUBSTR(release_date, 9, 2) || '.' || SUBSTR(release_date, 6, 2) || '.' || SUBSTR(release_date, 1, 4)
+END
+WHERE release_date IS NOT NULL;
+
+ALTER TABLE songs DROP COLUMN release_precision;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- version is bumped by every update and backs the ETag of a song. SQLite
+-- cannot add a column defaulting to CURRENT_TIMESTAMP, so updated_at is
+-- filled from created_at.
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE songs ADD COLUMN updated_at TIMESTAMP;
+UPDATE songs SET updated_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs DROP COLUMN updated_at;
+ALTER TABLE songs DROP COLUMN version;
+-- +goose StatementEnd
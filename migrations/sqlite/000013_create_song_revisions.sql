@@ -0,0 +1,23 @@
+-- +goose Up
+-- Every change to a song stores a full snapshot of the song as it was
+-- afterwards, numbered per song, with the user or API key that made it.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    snapshot TEXT NOT NULL,
+    source_revision INTEGER,
+    actor VARCHAR(255) NOT NULL,
+    actor_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    actor_api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (song_id, revision)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Time-synced lyrics, one row per line in playback order. time_ms is the
+-- offset of the line from the start of the song.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS synced_lyrics (
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    time_ms INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, line),
+    UNIQUE (song_id, time_ms)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS synced_lyrics;
+-- +goose StatementEnd
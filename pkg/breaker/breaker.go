@@ -0,0 +1,166 @@
+// Package breaker implements a circuit breaker that stops calls to a
+// dependency after repeated failures and lets a single trial call through
+// once a cooldown has passed.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while the breaker rejects calls.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the position of a breaker.
+type State string
+
+const (
+	// Closed lets every call through.
+	Closed State = "closed"
+	// Open rejects calls until the cooldown has passed.
+	Open State = "open"
+	// HalfOpen lets one trial call through; its outcome closes the breaker
+	// or opens it again.
+	HalfOpen State = "half-open"
+)
+
+// Snapshot is the state of a breaker and its counters at one moment.
+// Failures counts every failed call, ConsecutiveFailures those since the
+// last success. OpenedAt and RetryAt are zero unless the breaker is open.
+type Snapshot struct {
+	State               State
+	ConsecutiveFailures int
+	Successes           uint64
+	Failures            uint64
+	Rejected            uint64
+	OpenedAt            time.Time
+	RetryAt             time.Time
+	LastError           string
+	LastFailureAt       time.Time
+}
+
+// Breaker opens after threshold consecutive failures and stays open for
+// cooldown. A threshold below 1 never opens. It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to State)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	probing  bool
+	snapshot Snapshot
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     Closed,
+	}
+}
+
+// OnStateChange sets a function called on every change of state. It runs
+// with the breaker locked and must not call back into it.
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// Allow reports whether a call may go ahead. Every allowed call must be
+// followed by Success or Failure.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.now().Before(b.snapshot.OpenedAt.Add(b.cooldown)) {
+			b.snapshot.Rejected++
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			b.snapshot.Rejected++
+			return ErrOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a call that went through.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.snapshot.Successes++
+	b.snapshot.ConsecutiveFailures = 0
+	b.probing = false
+	if b.state != Closed {
+		b.setState(Closed)
+	}
+}
+
+// Failure records a call that failed with err.
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.snapshot.Failures++
+	b.snapshot.ConsecutiveFailures++
+	b.snapshot.LastFailureAt = b.now()
+	if err != nil {
+		b.snapshot.LastError = err.Error()
+	}
+	b.probing = false
+
+	switch {
+	case b.state == HalfOpen:
+		b.open()
+	case b.state == Closed && b.threshold > 0 && b.snapshot.ConsecutiveFailures >= b.threshold:
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.snapshot.OpenedAt = b.now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Snapshot returns the current state and counters.
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := b.snapshot
+	snapshot.State = b.state
+	if b.state == Open {
+		snapshot.RetryAt = snapshot.OpenedAt.Add(b.cooldown)
+	} else {
+		snapshot.OpenedAt = time.Time{}
+	}
+	return snapshot
+}
@@ -0,0 +1,240 @@
+package breaker
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a manual time source for a Breaker.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, *clock) {
+	c := &clock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	b := New(threshold, cooldown)
+	b.now = c.now
+	return b, c
+}
+
+// step is one event in the life of a breaker: a call to Allow, Success or
+// Failure, or time passing.
+type step struct {
+	allow   bool
+	success bool
+	failure bool
+	wait    time.Duration
+	// wantErr is checked after allow, state after every step.
+	wantErr error
+	state   State
+}
+
+var errDown = errors.New("down")
+
+func TestBreaker(t *testing.T) {
+	allowed := func(state State) step { return step{allow: true, state: state} }
+	rejected := func(state State) step { return step{allow: true, wantErr: ErrOpen, state: state} }
+	succeed := func(state State) step { return step{success: true, state: state} }
+	fail := func(state State) step { return step{failure: true, state: state} }
+	wait := func(d time.Duration, state State) step { return step{wait: d, state: state} }
+
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{
+			name:      "opens after consecutive failures",
+			threshold: 3,
+			steps: []step{
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Open),
+				rejected(Open),
+			},
+		},
+		{
+			name:      "success resets the count",
+			threshold: 2,
+			steps: []step{
+				allowed(Closed), fail(Closed),
+				allowed(Closed), succeed(Closed),
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Open),
+			},
+		},
+		{
+			name:      "stays open until the cooldown has passed",
+			threshold: 1,
+			steps: []step{
+				allowed(Closed), fail(Open),
+				wait(59*time.Second, Open),
+				rejected(Open),
+				wait(time.Second, Open),
+				allowed(HalfOpen),
+			},
+		},
+		{
+			name:      "half-open lets a single probe through",
+			threshold: 1,
+			steps: []step{
+				allowed(Closed), fail(Open),
+				wait(time.Minute, Open),
+				allowed(HalfOpen),
+				rejected(HalfOpen),
+				rejected(HalfOpen),
+			},
+		},
+		{
+			name:      "successful probe closes",
+			threshold: 1,
+			steps: []step{
+				allowed(Closed), fail(Open),
+				wait(time.Minute, Open),
+				allowed(HalfOpen), succeed(Closed),
+				allowed(Closed), allowed(Closed),
+			},
+		},
+		{
+			name:      "failed probe opens again for a full cooldown",
+			threshold: 3,
+			steps: []step{
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Open),
+				wait(time.Minute, Open),
+				allowed(HalfOpen), fail(Open),
+				wait(30*time.Second, Open),
+				rejected(Open),
+				wait(30*time.Second, Open),
+				allowed(HalfOpen),
+			},
+		},
+		{
+			name:      "zero threshold never opens",
+			threshold: 0,
+			steps: []step{
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Closed),
+				allowed(Closed), fail(Closed),
+				allowed(Closed),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, c := newTestBreaker(tt.threshold, time.Minute)
+			for i, s := range tt.steps {
+				switch {
+				case s.allow:
+					if err := b.Allow(); !errors.Is(err, s.wantErr) {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, err, s.wantErr)
+					}
+				case s.success:
+					b.Success()
+				case s.failure:
+					b.Failure(errDown)
+				default:
+					c.t = c.t.Add(s.wait)
+				}
+				if got := b.State(); got != s.state {
+					t.Fatalf("step %d: state = %s, want %s", i, got, s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestHalfOpenAllowsOneConcurrentProbe(t *testing.T) {
+	b, c := newTestBreaker(1, time.Minute)
+	b.Allow()
+	b.Failure(errDown)
+	c.t = c.t.Add(time.Minute)
+
+	const callers = 50
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if b.Allow() == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Fatalf("%d probes allowed, want 1", allowed)
+	}
+	if rejected := b.Snapshot().Rejected; rejected != callers-1 {
+		t.Errorf("rejected = %d, want %d", rejected, callers-1)
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	b, c := newTestBreaker(1, time.Minute)
+	var transitions []string
+	b.OnStateChange(func(from, to State) {
+		transitions = append(transitions, string(from)+">"+string(to))
+	})
+
+	b.Allow()
+	b.Failure(errDown)
+	c.t = c.t.Add(time.Minute)
+	b.Allow()
+	b.Failure(errDown)
+	c.t = c.t.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	b.Allow()
+	b.Success()
+
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	b, c := newTestBreaker(2, time.Minute)
+	start := c.t
+
+	b.Allow()
+	b.Success()
+	b.Allow()
+	b.Failure(errDown)
+	c.t = c.t.Add(time.Second)
+	b.Allow()
+	b.Failure(errors.New("still down"))
+	b.Allow()
+
+	s := b.Snapshot()
+	if s.State != Open || s.ConsecutiveFailures != 2 || s.Successes != 1 || s.Failures != 2 || s.Rejected != 1 {
+		t.Errorf("snapshot = %+v", s)
+	}
+	if s.LastError != "still down" || !s.LastFailureAt.Equal(start.Add(time.Second)) {
+		t.Errorf("last failure = %q at %v", s.LastError, s.LastFailureAt)
+	}
+	if !s.OpenedAt.Equal(start.Add(time.Second)) || !s.RetryAt.Equal(start.Add(time.Minute+time.Second)) {
+		t.Errorf("opened at %v, retry at %v", s.OpenedAt, s.RetryAt)
+	}
+
+	c.t = c.t.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	s = b.Snapshot()
+	if s.State != Closed || s.ConsecutiveFailures != 0 || !s.OpenedAt.IsZero() || !s.RetryAt.IsZero() {
+		t.Errorf("snapshot after recovery = %+v", s)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -12,16 +13,62 @@ var ErrFailedParseEnv = errors.New("error, failed parse env")
 
 type Config struct {
 	DB struct {
+		Driver   string `envconfig:"DB_DRIVER" default:"postgres"`
 		Host     string `envconfig:"DB_HOST" default:"localhost"`
 		Port     string `envconfig:"DB_PORT" default:"5432"`
 		User     string `envconfig:"DB_USER" default:"postgres"`
 		Password string `envconfig:"DB_PASSWORD" default:"postgres"`
 		Name     string `envconfig:"DB_NAME" default:"music_api"`
+		Path     string `envconfig:"DB_PATH" default:"music_api.db"`
+		// MigrateOnStart applies pending embedded migrations before serving.
+		MigrateOnStart bool `envconfig:"MIGRATE_ON_START" default:"true"`
 	}
 	Server struct {
 		Port string `envconfig:"SERVER_PORT" default:"8080"`
+		// RequireIfMatch rejects song writes without an If-Match header
+		// with 428 instead of applying them unconditionally.
+		RequireIfMatch bool `envconfig:"REQUIRE_IF_MATCH" default:"false"`
+	}
+	Auth struct {
+		// JWTSecret signs access tokens. When empty a random key is generated
+		// at startup, so tokens do not survive a restart.
+		JWTSecret string        `envconfig:"JWT_SECRET"`
+		TokenTTL  time.Duration `envconfig:"JWT_TTL" default:"24h"`
+		// AnonymousRead lets callers without a token use read-only routes.
+		AnonymousRead bool `envconfig:"AUTH_ANONYMOUS_READ" default:"true"`
+	}
+	Trash struct {
+		// Retention is how long deleted songs stay restorable; a purge
+		// removes those trashed earlier.
+		Retention time.Duration `envconfig:"TRASH_RETENTION" default:"720h"`
 	}
 	ExternalAPI string `envconfig:"EXTERNAL_API_URL"`
+	Enrichment  struct {
+		// Timeout bounds each request to the external API, body included.
+		Timeout time.Duration `envconfig:"ENRICH_TIMEOUT" default:"5s"`
+		// Retries is how many times a request that timed out or got a 5xx
+		// is repeated, waiting Backoff, then twice as long each time up to
+		// MaxBackoff.
+		Retries    int           `envconfig:"ENRICH_RETRIES" default:"2"`
+		Backoff    time.Duration `envconfig:"ENRICH_BACKOFF" default:"200ms"`
+		MaxBackoff time.Duration `envconfig:"ENRICH_MAX_BACKOFF" default:"2s"`
+		// BreakerFailures consecutive failed requests stop calls to the
+		// external API for BreakerCooldown; 0 never stops them.
+		BreakerFailures int           `envconfig:"ENRICH_BREAKER_FAILURES" default:"5"`
+		BreakerCooldown time.Duration `envconfig:"ENRICH_BREAKER_COOLDOWN" default:"30s"`
+	}
+}
+
+// Redacted returns a copy of the config that is safe to log: the database
+// password and the token signing key are masked.
+func (c Config) Redacted() Config {
+	if c.DB.Password != "" {
+		c.DB.Password = "***"
+	}
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = "***"
+	}
+	return c
 }
 
 func InitConfig() (*Config, error) {
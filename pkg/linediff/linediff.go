@@ -0,0 +1,264 @@
+// Package linediff computes line-based differences with Myers' algorithm
+// and renders them in the unified diff format.
+package linediff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of an edit.
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Edit is one line of an edit script. A is the index of the line in the
+// old sequence and B in the new one; an insert has no A and a delete no B,
+// so those are -1.
+type Edit struct {
+	Op Op
+	A  int
+	B  int
+}
+
+// Diff returns a shortest edit script turning a into b. Lines compare with
+// equal, which receives indexes into a and b. It uses the linear-space
+// variant of the algorithm, which splits the problem at the middle snake of
+// an optimal path, so memory stays proportional to n+m.
+func Diff(n, m int, equal func(i, j int) bool) []Edit {
+	if n+m == 0 {
+		return nil
+	}
+	d := differ{equal: equal, edits: make([]Edit, 0, n+m)}
+	d.compare(0, n, 0, m)
+	return d.edits
+}
+
+type differ struct {
+	equal func(i, j int) bool
+	edits []Edit
+}
+
+// compare appends the script turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.equal(aLo, bLo) {
+		d.edits = append(d.edits, Edit{Op: Equal, A: aLo, B: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi && bLo < bHi && d.equal(aHi-1, bHi-1) {
+		aHi--
+		bHi--
+		suffix++
+	}
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.edits = append(d.edits, Edit{Op: Insert, A: -1, B: j})
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.edits = append(d.edits, Edit{Op: Delete, A: i, B: -1})
+		}
+	default:
+		// Both halves of an optimal path are cheaper than the whole, so
+		// the recursion ends.
+		x, y, ok := d.middle(aLo, aHi, bLo, bHi)
+		if ok {
+			d.compare(aLo, x, bLo, y)
+			d.compare(x, aHi, y, bHi)
+		} else {
+			for i := aLo; i < aHi; i++ {
+				d.edits = append(d.edits, Edit{Op: Delete, A: i, B: -1})
+			}
+			for j := bLo; j < bHi; j++ {
+				d.edits = append(d.edits, Edit{Op: Insert, A: -1, B: j})
+			}
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, Edit{Op: Equal, A: aHi + i, B: bHi + i})
+	}
+}
+
+// middle searches from both ends of a[aLo:aHi] and b[bLo:bHi] at once and
+// returns a point where the paths meet, which lies on a shortest edit
+// script. Diagonal k holds the furthest x reached with x-y = k; the reverse
+// search counts x and y from the ends.
+func (d *differ) middle(aLo, aHi, bLo, bHi int) (x, y int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	reverse := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], reverse[i] = -1, -1
+	}
+	forward[offset+1], reverse[offset+1] = 0, 0
+
+	delta := n - m
+	// With an odd delta the paths meet while extending the forward one.
+	odd := delta%2 != 0
+	// Diagonals that ran off the grid are skipped from then on.
+	var fStart, fEnd, rStart, rEnd int
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			var fx int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				fx = forward[offset+k+1]
+			} else {
+				fx = forward[offset+k-1] + 1
+			}
+			fy := fx - k
+			for fx < n && fy < m && d.equal(aLo+fx, bLo+fy) {
+				fx++
+				fy++
+			}
+			forward[offset+k] = fx
+			switch {
+			case fx > n:
+				fEnd += 2
+			case fy > m:
+				fStart += 2
+			case odd:
+				rk := offset + delta - k
+				if rk >= 0 && rk < len(reverse) && reverse[rk] != -1 && fx >= n-reverse[rk] {
+					return aLo + fx, bLo + fy, true
+				}
+			}
+		}
+
+		for k := -step + rStart; k <= step-rEnd; k += 2 {
+			var rx int
+			if k == -step || (k != step && reverse[offset+k-1] < reverse[offset+k+1]) {
+				rx = reverse[offset+k+1]
+			} else {
+				rx = reverse[offset+k-1] + 1
+			}
+			ry := rx - k
+			for rx < n && ry < m && d.equal(aHi-rx-1, bHi-ry-1) {
+				rx++
+				ry++
+			}
+			reverse[offset+k] = rx
+			switch {
+			case rx > n:
+				rEnd += 2
+			case ry > m:
+				rStart += 2
+			case !odd:
+				fk := offset + delta - k
+				if fk >= 0 && fk < len(forward) && forward[fk] != -1 {
+					fx := forward[fk]
+					fy := fx - (delta - k)
+					if fx >= n-rx {
+						return aLo + fx, bLo + fy, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Strings diffs two slices of lines compared as plain strings.
+func Strings(a, b []string) []Edit {
+	return Diff(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+}
+
+// Unified renders edits of a into b as a unified diff with context lines
+// of unchanged text around each change. It returns an empty string when
+// nothing changed.
+func Unified(fromName, toName string, a, b []string, edits []Edit, context int) string {
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change and the extent of the hunk around it.
+		first := start
+		for first < len(edits) && edits[first].Op == Equal {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		lo := first - context
+		if lo < start {
+			lo = start
+		}
+		hi := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].Op != Equal {
+				hi = i + 1
+				continue
+			}
+			if i-hi >= 2*context {
+				break
+			}
+		}
+		hi += context
+		if hi > len(edits) {
+			hi = len(edits)
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		aBefore, bBefore := 0, 0
+		for _, e := range edits[:lo] {
+			if e.A >= 0 {
+				aBefore++
+			}
+			if e.B >= 0 {
+				bBefore++
+			}
+		}
+		writeHunk(&out, a, b, edits[lo:hi], aBefore, bBefore)
+		start = hi
+	}
+	return out.String()
+}
+
+// writeHunk writes one hunk. aBefore and bBefore count the lines of each
+// side that precede it.
+func writeHunk(out *strings.Builder, a, b []string, edits []Edit, aBefore, bBefore int) {
+	aLen, bLen := 0, 0
+	for _, e := range edits {
+		if e.A >= 0 {
+			aLen++
+		}
+		if e.B >= 0 {
+			bLen++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aBefore, aLen), hunkRange(bBefore, bLen))
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			out.WriteString(" " + a[e.A] + "\n")
+		case Delete:
+			out.WriteString("-" + a[e.A] + "\n")
+		case Insert:
+			out.WriteString("+" + b[e.B] + "\n")
+		}
+	}
+}
+
+// hunkRange formats the start,length pair of one side of a hunk. Like GNU
+// diff, an empty side names the line before the hunk and a single line
+// omits the length.
+func hunkRange(before, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, length)
+}
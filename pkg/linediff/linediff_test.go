@@ -0,0 +1,210 @@
+package linediff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "")
+}
+
+// script renders edits as one letter per edit: = keeps, + inserts and -
+// deletes a line.
+func script(edits []Edit) string {
+	var b strings.Builder
+	for _, e := range edits {
+		b.WriteString([]string{"=", "+", "-"}[e.Op])
+	}
+	return b.String()
+}
+
+// check fails unless edits turn a into b, visiting every line of each side
+// once and in order.
+func check(t *testing.T, a, b []string, edits []Edit) {
+	t.Helper()
+	i, j := 0, 0
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			if e.A != i || e.B != j || a[i] != b[j] {
+				t.Fatalf("bad equal %+v at %d,%d", e, i, j)
+			}
+			i++
+			j++
+		case Delete:
+			if e.A != i || e.B != -1 {
+				t.Fatalf("bad delete %+v at %d,%d", e, i, j)
+			}
+			i++
+		case Insert:
+			if e.A != -1 || e.B != j {
+				t.Fatalf("bad insert %+v at %d,%d", e, i, j)
+			}
+			j++
+		}
+	}
+	if i != len(a) || j != len(b) {
+		t.Fatalf("script stops at %d,%d of %d,%d", i, j, len(a), len(b))
+	}
+}
+
+// cost counts the inserts and deletes of edits.
+func cost(edits []Edit) int {
+	n := 0
+	for _, e := range edits {
+		if e.Op != Equal {
+			n++
+		}
+	}
+	return n
+}
+
+// lcs is the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	row := make([]int, len(b)+1)
+	for i := range a {
+		prev := 0
+		for j := range b {
+			cur := row[j+1]
+			if a[i] == b[j] {
+				row[j+1] = prev + 1
+			} else if row[j] > row[j+1] {
+				row[j+1] = row[j]
+			}
+			prev = cur
+		}
+	}
+	return row[len(b)]
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   string
+		script string
+	}{
+		{name: "both empty", a: "", b: "", script: ""},
+		{name: "all insert", a: "", b: "abc", script: "+++"},
+		{name: "all delete", a: "abc", b: "", script: "---"},
+		{name: "equal", a: "abc", b: "abc", script: "==="},
+		{name: "replace all", a: "ab", b: "xy", script: "--++"},
+		{name: "insert in the middle", a: "ac", b: "abc", script: "=+="},
+		{name: "delete in the middle", a: "abc", b: "ac", script: "=-="},
+		{name: "change one line", a: "abc", b: "axc", script: "=-+="},
+		{name: "insert at both ends", a: "b", b: "abc", script: "+=+"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := lines(tt.a), lines(tt.b)
+			edits := Strings(a, b)
+			check(t, a, b, edits)
+			if got := script(edits); got != tt.script {
+				t.Errorf("script = %q, want %q", got, tt.script)
+			}
+		})
+	}
+}
+
+func TestStringsIsMinimal(t *testing.T) {
+	// The example of Myers' paper: the shortest script has 5 edits.
+	a, b := lines("abcabba"), lines("cbabac")
+	edits := Strings(a, b)
+	check(t, a, b, edits)
+	if got := cost(edits); got != 5 {
+		t.Errorf("cost = %d, want 5: %s", got, script(edits))
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		s := make([]string, rng.Intn(40))
+		for i := range s {
+			s[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return s
+	}
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		edits := Strings(a, b)
+		check(t, a, b, edits)
+		if got, want := cost(edits), len(a)+len(b)-2*lcs(a, b); got != want {
+			t.Fatalf("%q -> %q: cost = %d, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	numbered := func(n int) []string {
+		s := make([]string, n)
+		for i := range s {
+			s[i] = string(rune('a' + i))
+		}
+		return s
+	}
+	replace := func(s []string, i int, line string) []string {
+		s = append([]string(nil), s...)
+		s[i] = line
+		return s
+	}
+
+	base := numbered(12)
+	tests := []struct {
+		name    string
+		a, b    []string
+		context int
+		want    string
+	}{
+		{name: "no changes", a: base, b: base, context: 3, want: ""},
+		{
+			name: "one change",
+			a:    base, b: replace(base, 5, "X"), context: 2,
+			want: "--- old\n+++ new\n@@ -4,5 +4,5 @@\n d\n e\n-f\n+X\n g\n h\n",
+		},
+		{
+			name: "changes close together share a hunk",
+			a:    base, b: replace(replace(base, 2, "X"), 6, "Y"), context: 2,
+			want: "--- old\n+++ new\n@@ -1,9 +1,9 @@\n a\n b\n-c\n+X\n d\n e\n f\n-g\n+Y\n h\n i\n",
+		},
+		{
+			name: "changes far apart get their own hunks",
+			a:    base, b: replace(replace(base, 1, "X"), 10, "Y"), context: 1,
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+X\n c\n@@ -10,3 +10,3 @@\n j\n-k\n+Y\n l\n",
+		},
+		{
+			name: "insert into empty",
+			a:    nil, b: []string{"a", "b"}, context: 3,
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "delete a single line",
+			a:    []string{"a"}, b: nil, context: 3,
+			want: "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "append after context",
+			a:    numbered(5), b: append(numbered(5), "X"), context: 2,
+			want: "--- old\n+++ new\n@@ -4,2 +4,3 @@\n d\n e\n+X\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("old", "new", tt.a, tt.b, Strings(tt.a, tt.b), tt.context)
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	if edits := Diff(0, 0, nil); !reflect.DeepEqual(edits, []Edit(nil)) {
+		t.Errorf("Diff(0, 0) = %v, want nil", edits)
+	}
+}
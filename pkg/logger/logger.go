@@ -27,4 +27,8 @@ func (l *Logger) Info(msg string, fields logrus.Fields) {
 
 func (l *Logger) Error(msg string, fields logrus.Fields) {
     l.logger.WithFields(fields).Error(msg)
-}
\ No newline at end of file
+}
+
+func (l *Logger) Warn(msg string, fields logrus.Fields) {
+    l.logger.WithFields(fields).Warn(msg)
+}
@@ -0,0 +1,204 @@
+// Package lrc reads and writes time-synced lyrics in the LRC format:
+// "[mm:ss.xx]text" lines, optionally preceded by "[ti:...]"-style tags.
+package lrc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrSyntax means a line is neither a tag nor a timed lyric line.
+	ErrSyntax = errors.New("invalid LRC line")
+	// ErrOrder means the timestamps of the lines do not increase.
+	ErrOrder = errors.New("LRC timestamps must increase from line to line")
+	// ErrTimestamp means a timestamp is malformed.
+	ErrTimestamp = errors.New("timestamp must be mm:ss or mm:ss.xx")
+)
+
+// Line is a lyric line shown from Time on.
+type Line struct {
+	Time time.Duration
+	Text string
+}
+
+// Lyrics is a parsed LRC document. Tags holds the ID tags such as "ti"
+// (title) and "ar" (artist); the offset tag is applied to the lines
+// rather than kept.
+type Lyrics struct {
+	Tags  map[string]string
+	Lines []Line
+}
+
+var (
+	timeTag = regexp.MustCompile(`^\[(\d+:\d{1,2}(?:[.:]\d{1,3})?)\]`)
+	idTag   = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]$`)
+)
+
+// ParseTimestamp parses "mm:ss", "mm:ss.xx" or "mm:ss.xxx". Minutes may
+// exceed 59.
+func ParseTimestamp(value string) (time.Duration, error) {
+	minutes, rest, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, ErrTimestamp
+	}
+	seconds, fraction, _ := strings.Cut(rest, ".")
+	if !strings.Contains(rest, ".") {
+		// Some files write the hundredths after a second colon.
+		seconds, fraction, _ = strings.Cut(rest, ":")
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 {
+		return 0, ErrTimestamp
+	}
+	s, err := strconv.Atoi(seconds)
+	if err != nil || s < 0 || s > 59 || len(seconds) > 2 {
+		return 0, ErrTimestamp
+	}
+	d := time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if fraction != "" {
+		if len(fraction) > 3 {
+			return 0, ErrTimestamp
+		}
+		f, err := strconv.Atoi(fraction)
+		if err != nil || f < 0 {
+			return 0, ErrTimestamp
+		}
+		for i := len(fraction); i < 3; i++ {
+			f *= 10
+		}
+		d += time.Duration(f) * time.Millisecond
+	}
+	return d, nil
+}
+
+// FormatTimestamp renders d as "mm:ss.xx".
+func FormatTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	hundredths := int64(d / (10 * time.Millisecond))
+	return fmt.Sprintf("%02d:%02d.%02d", hundredths/6000, hundredths/100%60, hundredths%100)
+}
+
+// Parse reads an LRC document. A line may carry several timestamps when it
+// repeats, as in "[00:12.00][01:30.00]chorus"; it is then listed once per
+// timestamp. Apart from such repeats, each line must start later than the
+// one before it. Blank lines are skipped.
+func Parse(r io.Reader) (Lyrics, error) {
+	lyrics := Lyrics{Tags: make(map[string]string)}
+	var (
+		offset time.Duration
+		last   = time.Duration(-1)
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" {
+			continue
+		}
+
+		var times []time.Duration
+		for {
+			m := timeTag.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			t, err := ParseTimestamp(m[1])
+			if err != nil {
+				return Lyrics{}, fmt.Errorf("line %d: %w", n, err)
+			}
+			times = append(times, t)
+			line = line[len(m[0]):]
+		}
+
+		if len(times) == 0 {
+			m := idTag.FindStringSubmatch(line)
+			if m == nil {
+				return Lyrics{}, fmt.Errorf("%w: line %d has no timestamp", ErrSyntax, n)
+			}
+			key, value := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+			if key == "offset" {
+				ms, err := strconv.Atoi(value)
+				if err != nil {
+					return Lyrics{}, fmt.Errorf("%w: line %d: offset must be milliseconds", ErrSyntax, n)
+				}
+				offset = time.Duration(ms) * time.Millisecond
+				continue
+			}
+			lyrics.Tags[key] = value
+			continue
+		}
+
+		if times[0] <= last {
+			return Lyrics{}, fmt.Errorf("%w: line %d at %s comes after %s", ErrOrder, n, FormatTimestamp(times[0]), FormatTimestamp(last))
+		}
+		last = times[0]
+		text := strings.TrimSpace(line)
+		for _, t := range times {
+			lyrics.Lines = append(lyrics.Lines, Line{Time: t, Text: text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Lyrics{}, err
+	}
+
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool { return lyrics.Lines[i].Time < lyrics.Lines[j].Time })
+	for i := range lyrics.Lines {
+		// A positive offset shows the lyrics earlier.
+		t := lyrics.Lines[i].Time - offset
+		if t < 0 {
+			t = 0
+		}
+		lyrics.Lines[i].Time = t
+		if i > 0 && t <= lyrics.Lines[i-1].Time {
+			return Lyrics{}, fmt.Errorf("%w: two lines at %s", ErrOrder, FormatTimestamp(t))
+		}
+	}
+	return lyrics, nil
+}
+
+// tagOrder is the order in which Write emits the common tags.
+var tagOrder = []string{"ti", "ar", "al", "au", "by", "length", "re", "ve"}
+
+// Write renders lyrics as LRC, tags first.
+func Write(w io.Writer, lyrics Lyrics) error {
+	out := bufio.NewWriter(w)
+	written := make(map[string]bool)
+	for _, key := range tagOrder {
+		if value, ok := lyrics.Tags[key]; ok && value != "" {
+			fmt.Fprintf(out, "[%s:%s]\n", key, oneLine(value))
+			written[key] = true
+		}
+	}
+	var rest []string
+	for key := range lyrics.Tags {
+		if !written[key] && lyrics.Tags[key] != "" {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		fmt.Fprintf(out, "[%s:%s]\n", key, oneLine(lyrics.Tags[key]))
+	}
+	for _, line := range lyrics.Lines {
+		fmt.Fprintf(out, "[%s]%s\n", FormatTimestamp(line.Time), oneLine(line.Text))
+	}
+	return out.Flush()
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
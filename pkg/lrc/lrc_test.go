@@ -0,0 +1,218 @@
+package lrc
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "00:00", want: 0},
+		{in: "01:02", want: ms(62000)},
+		{in: "01:02.5", want: ms(62500)},
+		{in: "01:02.05", want: ms(62050)},
+		{in: "01:02.005", want: ms(62005)},
+		{in: "01:02:50", want: ms(62500)},
+		{in: "75:00", want: 75 * time.Minute},
+		{in: " 1:2 ", want: ms(62000)},
+		{in: "62", wantErr: true},
+		{in: "01:60", wantErr: true},
+		{in: "01:002", wantErr: true},
+		{in: "01:02.1234", wantErr: true},
+		{in: "-1:02", wantErr: true},
+		{in: "aa:bb", wantErr: true},
+		{in: "01:02.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTimestamp) {
+					t.Fatalf("got %v, %v; want %v", got, err, ErrTimestamp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 0, want: "00:00.00"},
+		{in: ms(62509), want: "01:02.50"},
+		{in: 75 * time.Minute, want: "75:00.00"},
+		{in: -time.Second, want: "00:00.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatTimestamp(tt.in); got != tt.want {
+			t.Errorf("FormatTimestamp(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantTags map[string]string
+		want     []Line
+		wantErr  error
+	}{
+		{
+			name:     "tags and lines",
+			input:    "\ufeff[ti: Uprising ]\n[ar:Muse]\n\n[00:01.00]Paranoia is in bloom\n[00:04.50] The PR transmissions will resume \n",
+			wantTags: map[string]string{"ti": "Uprising", "ar": "Muse"},
+			want: []Line{
+				{Time: ms(1000), Text: "Paranoia is in bloom"},
+				{Time: ms(4500), Text: "The PR transmissions will resume"},
+			},
+		},
+		{
+			name:  "repeated line is listed once per timestamp",
+			input: "[00:10.00][01:30.00]Chorus\n[00:20.00]Verse",
+			want: []Line{
+				{Time: ms(10000), Text: "Chorus"},
+				{Time: ms(20000), Text: "Verse"},
+				{Time: ms(90000), Text: "Chorus"},
+			},
+		},
+		{
+			name:  "repeats may be listed in any order",
+			input: "[00:10.00][02:00.00][01:00.00]Chorus\n[00:30.00]Verse\n[01:30.00]Bridge",
+			want: []Line{
+				{Time: ms(10000), Text: "Chorus"},
+				{Time: ms(30000), Text: "Verse"},
+				{Time: ms(60000), Text: "Chorus"},
+				{Time: ms(90000), Text: "Bridge"},
+				{Time: ms(120000), Text: "Chorus"},
+			},
+		},
+		{
+			name:  "empty text keeps its time",
+			input: "[00:01.00]Line\n[00:03.00]\n[00:05.00]Line",
+			want: []Line{
+				{Time: ms(1000), Text: "Line"},
+				{Time: ms(3000), Text: ""},
+				{Time: ms(5000), Text: "Line"},
+			},
+		},
+		{
+			name:  "positive offset shows lines earlier",
+			input: "[offset:+500]\n[00:00.20]a\n[00:02.00]b",
+			want: []Line{
+				{Time: 0, Text: "a"},
+				{Time: ms(1500), Text: "b"},
+			},
+		},
+		{
+			name:  "negative offset shows lines later",
+			input: "[offset:-250]\n[00:01.00]a",
+			want:  []Line{{Time: ms(1250), Text: "a"}},
+		},
+		{
+			name:    "repeat collides with a later line",
+			input:   "[00:10.00][00:20.00]Chorus\n[00:20.00]Verse",
+			wantErr: ErrOrder,
+		},
+		{
+			name:    "offset collapses two lines",
+			input:   "[offset:1000]\n[00:00.10]a\n[00:00.50]b",
+			wantErr: ErrOrder,
+		},
+		{
+			name:    "line before the previous one",
+			input:   "[00:05.00]b\n[00:01.00]a",
+			wantErr: ErrOrder,
+		},
+		{
+			name:    "same time twice",
+			input:   "[00:05.00]a\n[00:05.00]b",
+			wantErr: ErrOrder,
+		},
+		{
+			name:    "line without a timestamp",
+			input:   "[00:05.00]a\njust text",
+			wantErr: ErrSyntax,
+		},
+		{
+			name:    "offset is not a number",
+			input:   "[offset:soon]\n[00:05.00]a",
+			wantErr: ErrSyntax,
+		},
+		{
+			name:    "seconds out of range",
+			input:   "[00:75.00]a",
+			wantErr: ErrTimestamp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.Lines, tt.want) {
+				t.Errorf("lines = %+v, want %+v", got.Lines, tt.want)
+			}
+			wantTags := tt.wantTags
+			if wantTags == nil {
+				wantTags = map[string]string{}
+			}
+			if !reflect.DeepEqual(got.Tags, wantTags) {
+				t.Errorf("tags = %v, want %v", got.Tags, wantTags)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	lyrics := Lyrics{
+		Tags: map[string]string{"zz": "last", "ar": "Muse", "ti": "Uprising", "x": "", "by": "two\nlines"},
+		Lines: []Line{
+			{Time: ms(1000), Text: "Paranoia is in bloom"},
+			{Time: ms(62505), Text: "They will not\nforce us"},
+		},
+	}
+	var b strings.Builder
+	if err := Write(&b, lyrics); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := "[ti:Uprising]\n[ar:Muse]\n[by:two lines]\n[zz:last]\n[00:01.00]Paranoia is in bloom\n[01:02.50]They will not force us\n"
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+
+	parsed, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("parse written lyrics: %v", err)
+	}
+	if len(parsed.Lines) != 2 || parsed.Lines[1].Time != ms(62500) || parsed.Tags["ti"] != "Uprising" {
+		t.Errorf("round trip = %+v", parsed)
+	}
+}
@@ -0,0 +1,254 @@
+// Package lyricsection splits song lyrics into labeled sections such as
+// verses and choruses. Sections are taken from markers like "[Chorus]",
+// "[Verse 2]" or "Bridge:" where the lyrics have them; blocks without a
+// marker that occur more than once are taken to be choruses and the rest
+// verses.
+package lyricsection
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Type is the kind of a section.
+type Type string
+
+const (
+	Intro  Type = "intro"
+	Verse  Type = "verse"
+	Chorus Type = "chorus"
+	Bridge Type = "bridge"
+	Outro  Type = "outro"
+)
+
+// Types lists the section types in the order they usually appear.
+var Types = []Type{Intro, Verse, Chorus, Bridge, Outro}
+
+// ParseType reads the name of a section type, ignoring case.
+func ParseType(name string) (Type, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, t := range Types {
+		if string(t) == name {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// Section is a block of lyrics. Number tells apart sections of the same
+// type, as in "Verse 2"; choruses and other sections that are not numbered
+// in the lyrics have 0. RepeatOf is the 1-based position of the earlier
+// section this one repeats, or 0.
+type Section struct {
+	Type     Type
+	Number   int
+	Lines    []string
+	RepeatOf int
+}
+
+// Label is the heading of the section, such as "Verse 2" or "Chorus".
+func (s Section) Label() string {
+	name := string(s.Type)
+	name = strings.ToUpper(name[:1]) + name[1:]
+	if s.Number > 0 {
+		return fmt.Sprintf("%s %d", name, s.Number)
+	}
+	return name
+}
+
+// markerTypes maps the names used in section markers to their type.
+var markerTypes = map[string]Type{
+	"intro":      Intro,
+	"verse":      Verse,
+	"chorus":     Chorus,
+	"refrain":    Chorus,
+	"hook":       Chorus,
+	"pre-chorus": Bridge,
+	"prechorus":  Bridge,
+	"bridge":     Bridge,
+	"outro":      Outro,
+}
+
+// marker matches a line naming a section: "[Verse 2]", "(Chorus x2)",
+// "Bridge:" or a bare "Chorus". Text after the name, such as the singer or
+// a repeat count, is allowed inside brackets only.
+var marker = regexp.MustCompile(`(?i)^(?:[\[(]\s*(intro|verse|chorus|refrain|hook|pre-?chorus|bridge|outro)\s*(\d+)?\b[^\])]*[\])]|(intro|verse|chorus|refrain|hook|pre-?chorus|bridge|outro)\s*(\d+)?\s*:?)$`)
+
+func parseMarker(line string) (t Type, number int, ok bool) {
+	m := marker.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", 0, false
+	}
+	name, digits := m[1], m[2]
+	if name == "" {
+		name, digits = m[3], m[4]
+	}
+	number, _ = strconv.Atoi(digits)
+	return markerTypes[strings.ToLower(name)], number, true
+}
+
+// block is a run of lines before typing, with the marker that opened it.
+type block struct {
+	marked bool
+	typ    Type
+	number int
+	lines  []string
+}
+
+// Parse splits lyrics into sections. Blank lines and markers start a new
+// section. A marker with no lines of its own, as in a lone "[Chorus]",
+// repeats the last section of its type.
+func Parse(lyrics string) []Section {
+	lyrics = strings.ReplaceAll(lyrics, "\r\n", "\n")
+
+	var (
+		blocks  []block
+		current *block
+	)
+	// repeats reports whether the current block is a marker standing for
+	// an earlier section.
+	repeats := func() (int, bool) {
+		if current == nil || !current.marked || len(current.lines) > 0 {
+			return -1, false
+		}
+		earlier := lastOfType(blocks, current.typ, current.number)
+		return earlier, earlier >= 0
+	}
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if earlier, ok := repeats(); ok {
+			repeat := *current
+			repeat.lines = blocks[earlier].lines
+			if repeat.number == 0 {
+				repeat.number = blocks[earlier].number
+			}
+			blocks = append(blocks, repeat)
+		} else if len(current.lines) > 0 {
+			blocks = append(blocks, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(lyrics, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// A marker followed by a blank line still labels the lines
+			// after it, unless it repeats an earlier section.
+			if _, ok := repeats(); ok || (current != nil && len(current.lines) > 0) {
+				flush()
+			}
+			continue
+		}
+		if t, number, ok := parseMarker(line); ok {
+			flush()
+			current = &block{marked: true, typ: t, number: number}
+			continue
+		}
+		if current == nil {
+			current = &block{}
+		}
+		current.lines = append(current.lines, line)
+	}
+	flush()
+
+	return sections(blocks)
+}
+
+// lastOfType returns the index of the last block of type t with lines,
+// preferring one numbered number when it is not 0, or -1.
+func lastOfType(blocks []block, t Type, number int) int {
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i].typ == t && len(blocks[i].lines) > 0 && (number == 0 || blocks[i].number == number) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sections types the unmarked blocks and links repeats. An unmarked block
+// takes the type of a marked block with the same text; otherwise it is a
+// chorus when its text occurs more than once and a verse when it does not.
+func sections(blocks []block) []Section {
+	counts := make(map[string]int)
+	marked := make(map[string]block)
+	for _, b := range blocks {
+		key := blockKey(b.lines)
+		counts[key]++
+		if _, ok := marked[key]; b.marked && !ok {
+			marked[key] = b
+		}
+	}
+
+	var (
+		result []Section
+		first  = make(map[string]int)
+		verses int
+	)
+	for _, b := range blocks {
+		key := blockKey(b.lines)
+		if !b.marked {
+			if m, ok := marked[key]; ok {
+				b.typ, b.number = m.typ, m.number
+			} else if counts[key] > 1 {
+				b.typ = Chorus
+			} else {
+				b.typ = Verse
+			}
+		}
+		key = string(b.typ) + "\n" + key
+		if b.typ == Verse {
+			if _, ok := first[key]; b.number == 0 && !ok {
+				b.number = verses + 1
+			}
+			if b.number > verses {
+				verses = b.number
+			}
+		}
+
+		section := Section{Type: b.typ, Number: b.number, Lines: b.lines}
+		if position, ok := first[key]; ok {
+			section.RepeatOf = position
+			section.Number = result[position-1].Number
+		} else {
+			first[key] = len(result) + 1
+		}
+		result = append(result, section)
+	}
+	return result
+}
+
+// blockKey compares blocks ignoring case, punctuation and spacing.
+func blockKey(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		for _, r := range strings.ToLower(line) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(r)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Format renders sections as lyrics with a "[Label]" marker before each
+// one, which Parse reads back into the same sections.
+func Format(sections []Section) string {
+	var b strings.Builder
+	for i, s := range sections {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%s]", s.Label())
+		for _, line := range s.Lines {
+			b.WriteString("\n")
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
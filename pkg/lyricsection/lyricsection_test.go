@@ -0,0 +1,184 @@
+package lyricsection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Type
+		ok   bool
+	}{
+		{in: "verse", want: Verse, ok: true},
+		{in: " Chorus ", want: Chorus, ok: true},
+		{in: "OUTRO", want: Outro, ok: true},
+		{in: "refrain", ok: false},
+		{in: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseType(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseType(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		section Section
+		want    string
+	}{
+		{section: Section{Type: Verse, Number: 2}, want: "Verse 2"},
+		{section: Section{Type: Chorus}, want: "Chorus"},
+		{section: Section{Type: Intro}, want: "Intro"},
+	}
+	for _, tt := range tests {
+		if got := tt.section.Label(); got != tt.want {
+			t.Errorf("Label() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		lyrics string
+		want   []Section
+	}{
+		{
+			name:   "empty",
+			lyrics: " \n\n",
+			want:   nil,
+		},
+		{
+			name:   "unmarked blocks are verses",
+			lyrics: "a\nb\n\nc\nd",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"a", "b"}},
+				{Type: Verse, Number: 2, Lines: []string{"c", "d"}},
+			},
+		},
+		{
+			name:   "unmarked block that recurs is a chorus",
+			lyrics: "v1\n\nHey, jude!\n\nv2\n\nhey jude\n",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"v1"}},
+				{Type: Chorus, Lines: []string{"Hey, jude!"}},
+				{Type: Verse, Number: 2, Lines: []string{"v2"}},
+				{Type: Chorus, Lines: []string{"hey jude"}, RepeatOf: 2},
+			},
+		},
+		{
+			name:   "markers in several styles",
+			lyrics: "[Intro]\noh\n\nVerse 1:\nv1\n(Pre-Chorus)\npc\n[Chorus x2]\nc\n\nOutro\nbye",
+			want: []Section{
+				{Type: Intro, Lines: []string{"oh"}},
+				{Type: Verse, Number: 1, Lines: []string{"v1"}},
+				{Type: Bridge, Lines: []string{"pc"}},
+				{Type: Chorus, Lines: []string{"c"}},
+				{Type: Outro, Lines: []string{"bye"}},
+			},
+		},
+		{
+			name:   "marker followed by a blank line labels the lines after it",
+			lyrics: "[Verse 1]\n\nv1\n\n[Chorus]\n\nc",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"v1"}},
+				{Type: Chorus, Lines: []string{"c"}},
+			},
+		},
+		{
+			name:   "lone marker repeats the last section of its type",
+			lyrics: "[Verse 1]\nv1\n\n[Chorus]\nc1\nc2\n\n[Verse 2]\nv2\n\n[Chorus]\n\n[Verse 2]",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"v1"}},
+				{Type: Chorus, Lines: []string{"c1", "c2"}},
+				{Type: Verse, Number: 2, Lines: []string{"v2"}},
+				{Type: Chorus, Lines: []string{"c1", "c2"}, RepeatOf: 2},
+				{Type: Verse, Number: 2, Lines: []string{"v2"}, RepeatOf: 3},
+			},
+		},
+		{
+			name:   "numbered repeat marker picks the section with that number",
+			lyrics: "[Verse 1]\nfirst\n\n[Verse 2]\nsecond\n\n[Verse 1]\n",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"first"}},
+				{Type: Verse, Number: 2, Lines: []string{"second"}},
+				{Type: Verse, Number: 1, Lines: []string{"first"}, RepeatOf: 1},
+			},
+		},
+		{
+			name:   "repeat marker with nothing to repeat labels the next lines",
+			lyrics: "[Chorus]\n\nc\n\n[Chorus]",
+			want: []Section{
+				{Type: Chorus, Lines: []string{"c"}},
+				{Type: Chorus, Lines: []string{"c"}, RepeatOf: 1},
+			},
+		},
+		{
+			name:   "lone marker at the end with no earlier section is dropped",
+			lyrics: "v1\n\n[Bridge]",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"v1"}},
+			},
+		},
+		{
+			name:   "unmarked copy of a marked block takes its type",
+			lyrics: "[Chorus]\nla la\n\n[Verse]\nv\n\nLa la!",
+			want: []Section{
+				{Type: Chorus, Lines: []string{"la la"}},
+				{Type: Verse, Number: 1, Lines: []string{"v"}},
+				{Type: Chorus, Lines: []string{"La la!"}, RepeatOf: 1},
+			},
+		},
+		{
+			name:   "repeated verse text keeps its number",
+			lyrics: "[Verse 1]\nsame\n\n[Verse 2]\nother\n\n[Verse]\nsame",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"same"}},
+				{Type: Verse, Number: 2, Lines: []string{"other"}},
+				{Type: Verse, Number: 1, Lines: []string{"same"}, RepeatOf: 1},
+			},
+		},
+		{
+			name:   "windows line endings",
+			lyrics: "[Verse 1]\r\na\r\n\r\n[Chorus]\r\nb\r\n",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"a"}},
+				{Type: Chorus, Lines: []string{"b"}},
+			},
+		},
+		{
+			name:   "text after a bare name is a lyric line",
+			lyrics: "Chorus girls are dancing\nall night",
+			want: []Section{
+				{Type: Verse, Number: 1, Lines: []string{"Chorus girls are dancing", "all night"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.lyrics)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	lyrics := "[Intro]\noh\n\n[Verse 1]\nv1\n\n[Chorus]\nc\n\n[Verse 2]\nv2\n\n[Chorus]\n\n[Outro]\nbye"
+	sections := Parse(lyrics)
+
+	formatted := Format(sections)
+	want := "[Intro]\noh\n\n[Verse 1]\nv1\n\n[Chorus]\nc\n\n[Verse 2]\nv2\n\n[Chorus]\nc\n\n[Outro]\nbye"
+	if formatted != want {
+		t.Errorf("Format = %q, want %q", formatted, want)
+	}
+	if again := Parse(formatted); !reflect.DeepEqual(again, sections) {
+		t.Errorf("Parse(Format) = %+v, want %+v", again, sections)
+	}
+}
@@ -0,0 +1,273 @@
+// Package playlistfile reads and writes playlists in the M3U, M3U8 and XSPF
+// formats used by desktop players.
+package playlistfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrFormat = errors.New("unsupported playlist format")
+
+// Format is a playlist file format.
+type Format string
+
+const (
+	M3U  Format = "m3u"
+	M3U8 Format = "m3u8"
+	XSPF Format = "xspf"
+)
+
+// ContentType is the media type of a playlist file in format.
+func (f Format) ContentType() string {
+	switch f {
+	case M3U:
+		return "audio/x-mpegurl"
+	case M3U8:
+		return "application/vnd.apple.mpegurl"
+	}
+	return "application/xspf+xml"
+}
+
+// Track is one entry of a playlist. Duration is in seconds; -1 means
+// unknown.
+type Track struct {
+	Artist   string
+	Title    string
+	Location string
+	Duration int
+}
+
+// Playlist is a parsed playlist file.
+type Playlist struct {
+	Title  string
+	Tracks []Track
+}
+
+// Parse reads a playlist in format.
+func Parse(r io.Reader, format Format) (Playlist, error) {
+	switch format {
+	case M3U, M3U8:
+		return parseM3U(r)
+	case XSPF:
+		return parseXSPF(r)
+	}
+	return Playlist{}, ErrFormat
+}
+
+// parseM3U reads an M3U playlist. Extended playlists describe each location
+// with a preceding "#EXTINF:<seconds>,<artist> - <title>" line. Files that
+// are not valid UTF-8 are read as Latin-1, the traditional M3U encoding.
+func parseM3U(r io.Reader) (Playlist, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Playlist{}, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		data = []byte(string(runes))
+	}
+
+	var (
+		playlist Playlist
+		pending  *Track
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			duration, title, _ := strings.Cut(info, ",")
+			// Attributes such as tvg-id="..." may follow the duration.
+			duration, _, _ = strings.Cut(strings.TrimSpace(duration), " ")
+			track := Track{Duration: -1}
+			if seconds, err := strconv.Atoi(duration); err == nil && seconds >= 0 {
+				track.Duration = seconds
+			}
+			track.Artist, track.Title = splitTitle(title)
+			pending = &track
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			playlist.Title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#"):
+			// #EXTM3U and other directives.
+		default:
+			track := Track{Duration: -1}
+			if pending != nil {
+				track = *pending
+				pending = nil
+			} else {
+				track.Artist, track.Title = splitTitle(fileTitle(line))
+			}
+			track.Location = line
+			playlist.Tracks = append(playlist.Tracks, track)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Playlist{}, err
+	}
+	return playlist, nil
+}
+
+// fileTitle is the name of a playlist location without directories and
+// extension, which plain M3U files often use as "Artist - Title".
+func fileTitle(location string) string {
+	name := path.Base(strings.ReplaceAll(location, "\\", "/"))
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// splitTitle splits "Artist - Title"; without the separator everything is
+// the title.
+func splitTitle(s string) (artist, title string) {
+	s = strings.TrimSpace(s)
+	if artist, title, ok := strings.Cut(s, " - "); ok {
+		return strings.TrimSpace(artist), strings.TrimSpace(title)
+	}
+	return "", s
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Locations []string `xml:"location,omitempty"`
+	Creator   string   `xml:"creator,omitempty"`
+	Title     string   `xml:"title,omitempty"`
+	// Duration is in milliseconds.
+	Duration int `xml:"duration,omitempty"`
+}
+
+func parseXSPF(r io.Reader) (Playlist, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Playlist{}, fmt.Errorf("xspf: %w", err)
+	}
+	playlist := Playlist{Title: strings.TrimSpace(doc.Title)}
+	for _, t := range doc.Tracks {
+		track := Track{
+			Artist:   strings.TrimSpace(t.Creator),
+			Title:    strings.TrimSpace(t.Title),
+			Duration: -1,
+		}
+		if len(t.Locations) > 0 {
+			track.Location = strings.TrimSpace(t.Locations[0])
+		}
+		if t.Duration > 0 {
+			track.Duration = t.Duration / 1000
+		}
+		playlist.Tracks = append(playlist.Tracks, track)
+	}
+	return playlist, nil
+}
+
+// Writer writes a playlist one track at a time. Close must be called to
+// finish the file.
+type Writer struct {
+	w      io.Writer
+	format Format
+	err    error
+}
+
+// NewWriter starts a playlist named title in format. M3U and M3U8 are
+// written as extended UTF-8 playlists.
+func NewWriter(w io.Writer, format Format, title string) (*Writer, error) {
+	pw := &Writer{w: w, format: format}
+	switch format {
+	case M3U, M3U8:
+		pw.printf("#EXTM3U\n")
+		if title != "" {
+			pw.printf("#PLAYLIST:%s\n", oneLine(title))
+		}
+	case XSPF:
+		pw.printf("%s<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\">\n", xml.Header)
+		if title != "" {
+			pw.encode("title", title)
+		}
+		pw.printf("  <trackList>\n")
+	default:
+		return nil, ErrFormat
+	}
+	return pw, pw.err
+}
+
+func (pw *Writer) printf(format string, args ...interface{}) {
+	if pw.err == nil {
+		_, pw.err = fmt.Fprintf(pw.w, format, args...)
+	}
+}
+
+// encode writes v as an element of the given name on its own lines, indented
+// by its depth in the document.
+func (pw *Writer) encode(name string, v interface{}) {
+	indent := "  "
+	if name == "track" {
+		indent = "    "
+	}
+	if pw.err == nil {
+		enc := xml.NewEncoder(pw.w)
+		enc.Indent(indent, "  ")
+		pw.err = enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+	pw.printf("\n")
+}
+
+// oneLine keeps a value from breaking the line structure of M3U.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Write adds a track. M3U has no place for a track without a location, so
+// such tracks are left out of M3U files.
+func (pw *Writer) Write(t Track) error {
+	switch pw.format {
+	case M3U, M3U8:
+		if t.Location == "" {
+			return pw.err
+		}
+		duration := t.Duration
+		if duration < 0 {
+			duration = -1
+		}
+		title := oneLine(t.Title)
+		if t.Artist != "" {
+			title = oneLine(t.Artist) + " - " + title
+		}
+		pw.printf("#EXTINF:%d,%s\n%s\n", duration, title, oneLine(t.Location))
+	case XSPF:
+		track := xspfTrack{Creator: t.Artist, Title: t.Title}
+		if t.Location != "" {
+			track.Locations = []string{t.Location}
+		}
+		if t.Duration > 0 {
+			track.Duration = t.Duration * 1000
+		}
+		pw.encode("track", track)
+	}
+	return pw.err
+}
+
+// Close finishes the playlist.
+func (pw *Writer) Close() error {
+	if pw.format == XSPF {
+		pw.printf("  </trackList>\n</playlist>\n")
+	}
+	return pw.err
+}
@@ -0,0 +1,228 @@
+package playlistfile
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseM3U(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Playlist
+	}{
+		{
+			name:  "extended",
+			input: "#EXTM3U\n#PLAYLIST: Road trip \n#EXTINF:279,Muse - Uprising\nmusic/uprising.mp3\n#EXTINF:-1,Yesterday\nhttp://example.com/yesterday\n",
+			want: Playlist{Title: "Road trip", Tracks: []Track{
+				{Artist: "Muse", Title: "Uprising", Location: "music/uprising.mp3", Duration: 279},
+				{Title: "Yesterday", Location: "http://example.com/yesterday", Duration: -1},
+			}},
+		},
+		{
+			name:  "plain locations name the track",
+			input: "C:\\Music\\Muse - Starlight.mp3\r\n\r\n/srv/music/Hysteria.flac\r\n",
+			want: Playlist{Tracks: []Track{
+				{Artist: "Muse", Title: "Starlight", Location: "C:\\Music\\Muse - Starlight.mp3", Duration: -1},
+				{Title: "Hysteria", Location: "/srv/music/Hysteria.flac", Duration: -1},
+			}},
+		},
+		{
+			name:  "attributes after the duration",
+			input: "#EXTM3U\n#EXTINF:120 tvg-id=\"x\" group-title=\"Rock\",Muse - Madness\nmadness.mp3\n",
+			want: Playlist{Tracks: []Track{
+				{Artist: "Muse", Title: "Madness", Location: "madness.mp3", Duration: 120},
+			}},
+		},
+		{
+			name:  "malformed duration is unknown",
+			input: "#EXTINF:abc,Muse - Madness\nmadness.mp3\n",
+			want: Playlist{Tracks: []Track{
+				{Artist: "Muse", Title: "Madness", Location: "madness.mp3", Duration: -1},
+			}},
+		},
+		{
+			name:  "byte order mark",
+			input: "\xef\xbb\xbf#EXTM3U\n#EXTINF:1,Björk - Jóga\njoga.mp3\n",
+			want: Playlist{Tracks: []Track{
+				{Artist: "Björk", Title: "Jóga", Location: "joga.mp3", Duration: 1},
+			}},
+		},
+		{
+			name:  "latin-1",
+			input: "#EXTM3U\n#EXTINF:1,Bj\xf6rk - J\xf3ga\njoga.mp3\n",
+			want: Playlist{Tracks: []Track{
+				{Artist: "Björk", Title: "Jóga", Location: "joga.mp3", Duration: 1},
+			}},
+		},
+		{
+			name:  "directives are skipped",
+			input: "#EXTM3U\n#EXTGRP:Rock\n#EXTINF:10,Muse - Uprising\n#EXTALB:The Resistance\nuprising.mp3\n",
+			want: Playlist{Tracks: []Track{
+				{Artist: "Muse", Title: "Uprising", Location: "uprising.mp3", Duration: 10},
+			}},
+		},
+		{
+			name:  "empty",
+			input: "#EXTM3U\n",
+			want:  Playlist{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, format := range []Format{M3U, M3U8} {
+				got, err := Parse(strings.NewReader(tt.input), format)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", format, err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s: got %+v, want %+v", format, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseXSPF(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Playlist
+		wantErr bool
+	}{
+		{
+			name: "tracks",
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <title> Road trip </title>
+  <trackList>
+    <track>
+      <location>file:///music/uprising.mp3</location>
+      <location>http://example.com/uprising.mp3</location>
+      <creator>Muse</creator>
+      <title>Uprising</title>
+      <duration>305000</duration>
+    </track>
+    <track><title>Yesterday</title></track>
+  </trackList>
+</playlist>`,
+			want: Playlist{Title: "Road trip", Tracks: []Track{
+				{Artist: "Muse", Title: "Uprising", Location: "file:///music/uprising.mp3", Duration: 305},
+				{Title: "Yesterday", Duration: -1},
+			}},
+		},
+		{
+			name:    "other namespace",
+			input:   `<playlist version="1"><trackList/></playlist>`,
+			wantErr: true,
+		},
+		{
+			name:    "not XML",
+			input:   `#EXTM3U`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input), XSPF)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteM3U(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, M3U8, "Road\ntrip")
+	if err != nil {
+		t.Fatalf("new writer: %v", err)
+	}
+	for _, track := range []Track{
+		{Artist: "Muse", Title: "Uprising", Location: "uprising.mp3", Duration: 305},
+		{Title: "No location", Duration: 10},
+		{Title: "Two\nlines", Location: "two.mp3", Duration: -5},
+	} {
+		if err := w.Write(track); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	want := "#EXTM3U\n#PLAYLIST:Road trip\n#EXTINF:305,Muse - Uprising\nuprising.mp3\n#EXTINF:-1,Two lines\ntwo.mp3\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	playlist := Playlist{Title: "Mix <&>", Tracks: []Track{
+		{Artist: "Muse", Title: "Uprising", Location: "music/uprising.mp3", Duration: 305},
+		{Artist: "The Beatles", Title: "Let It Be", Location: "http://example.com/?a=1&b=2", Duration: -1},
+		{Title: "Björk - Jóga", Location: "joga.mp3", Duration: 0},
+	}}
+	// "Björk - Jóga" as a title reads back split into artist and title
+	// from M3U, which has no separate artist field.
+	m3u := Playlist{Title: playlist.Title, Tracks: []Track{
+		playlist.Tracks[0],
+		playlist.Tracks[1],
+		{Artist: "Björk", Title: "Jóga", Location: "joga.mp3", Duration: 0},
+	}}
+	// XSPF leaves out a zero duration.
+	xspf := Playlist{Title: playlist.Title, Tracks: []Track{
+		playlist.Tracks[0],
+		playlist.Tracks[1],
+		{Title: "Björk - Jóga", Location: "joga.mp3", Duration: -1},
+	}}
+
+	for format, want := range map[Format]Playlist{M3U: m3u, M3U8: m3u, XSPF: xspf} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format, playlist.Title)
+			if err != nil {
+				t.Fatalf("new writer: %v", err)
+			}
+			for _, track := range playlist.Tracks {
+				if err := w.Write(track); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			got, err := Parse(&buf, format)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	if _, err := Parse(strings.NewReader(""), "pls"); !errors.Is(err, ErrFormat) {
+		t.Errorf("Parse error = %v, want %v", err, ErrFormat)
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, "pls", ""); !errors.Is(err, ErrFormat) {
+		t.Errorf("NewWriter error = %v, want %v", err, ErrFormat)
+	}
+}
@@ -0,0 +1,44 @@
+// Package trigram computes trigram similarity the way PostgreSQL's pg_trgm
+// does, for storage backends without the extension.
+package trigram
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Threshold is the pg_trgm default for the % operator.
+const Threshold = 0.3
+
+// Trigrams returns the set of trigrams of s. Like pg_trgm, words are runs of
+// letters and digits, lowercased and padded with two spaces in front and
+// one behind.
+func Trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+// Similarity returns the share of trigrams a and b have in common, from 0
+// (nothing shared) to 1 (same trigram set).
+func Similarity(a, b string) float64 {
+	ta, tb := Trigrams(a), Trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
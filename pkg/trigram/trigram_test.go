@@ -0,0 +1,82 @@
+package trigram
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		// SELECT show_trgm('Cat');
+		{in: "Cat", want: []string{"  c", " ca", "at ", "cat"}},
+		// SELECT show_trgm('a-b');
+		{in: "a-b", want: []string{"  a", "  b", " a ", " b "}},
+		{in: "", want: []string{}},
+		{in: "?!", want: []string{}},
+	}
+	for _, tt := range tests {
+		want := make(map[string]struct{}, len(tt.want))
+		for _, trigram := range tt.want {
+			want[trigram] = struct{}{}
+		}
+		if got := Trigrams(tt.in); !reflect.DeepEqual(got, want) {
+			t.Errorf("Trigrams(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	// want is what SELECT similarity(a, b) returns with pg_trgm; it rounds
+	// to float4, hence the tolerance.
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "documentation example", a: "word", b: "two words", want: 0.36363637},
+		{name: "case folding", a: "Muse", b: "muse", want: 1},
+		{name: "non-ASCII case folding", a: "BJÖRK", b: "björk", want: 1},
+		{name: "punctuation splits words", a: "rock'n'roll", b: "Rock N Roll", want: 1},
+		{name: "repeated words count once", a: "la la la", b: "la", want: 1},
+		{name: "extra word", a: "Beatles", b: "The Beatles", want: 0.6666667},
+		{name: "multi-word", a: "Supermassive Black Hole", b: "black hole", want: 0.45833334},
+		{name: "digits are words", a: "Track 1", b: "track 2", want: 0.6},
+		{name: "typo", a: "hysteria", b: "hysterical", want: 0.53846157},
+		{name: "nothing shared", a: "muse", b: "abba", want: 0},
+		{name: "empty", a: "", b: "muse", want: 0},
+		{name: "both empty", a: "", b: "", want: 0},
+		{name: "only punctuation", a: "?!", b: "?!", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got, back := Similarity(tt.a, tt.b), Similarity(tt.b, tt.a); got != back {
+				t.Errorf("not symmetric: %v and %v", got, back)
+			}
+		})
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	// The % operator matches when the similarity reaches the threshold.
+	tests := []struct {
+		a, b  string
+		match bool
+	}{
+		{a: "cat", b: "catapult", match: true},   // 3/10, exactly the threshold
+		{a: "cat", b: "catapults", match: false}, // 3/11
+		{a: "muse", b: "mouse", match: true},     // 3/8
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b) >= Threshold; got != tt.match {
+			t.Errorf("%q %% %q = %v, want %v (similarity %v)", tt.a, tt.b, got, tt.match, Similarity(tt.a, tt.b))
+		}
+	}
+}